@@ -0,0 +1,92 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+type Type string
+
+const (
+	TypeEvm      Type = "evm"
+	TypeStarknet Type = "starknet"
+	// TypeSolana is an ed25519 public key, TypeSolanaPda an off curve program derived address
+	TypeSolana    Type = "solana"
+	TypeSolanaPda Type = "solana_pda"
+	TypeP2PKH     Type = "p2pkh"
+	TypeP2SH      Type = "p2sh"
+	TypeP2WPKH    Type = "p2wpkh"
+	TypeP2WSH     Type = "p2wsh"
+	TypeP2TR      Type = "p2tr"
+	TypeTonRaw    Type = "ton_raw"
+	// TypeTonBounceable and TypeTonNonBounceable are the base64 user friendly forms
+	TypeTonBounceable    Type = "ton_bounceable"
+	TypeTonNonBounceable Type = "ton_non_bounceable"
+	TypeCosmos           Type = "cosmos"
+	TypeTron             Type = "tron"
+	TypeBfc              Type = "bfc"
+)
+
+type Address struct {
+	Backend loader.Backend
+	Type    Type
+	// Canonical is the form to store and compare: checksummed hex for evm and starknet,
+	// lowercase for bech32, workchain:hex for ton, base58 for tron, 0x hex for bfc and the
+	// input as is otherwise
+	Canonical string
+	// Network is the bitcoin network, the ton testnet flag or the cosmos bech32 prefix
+	Network string
+}
+
+// Parse validates the address for the backend. The network selects the bitcoin network,
+// see BitcoinMainnet and friends, and the expected bech32 prefix for cosmos, where empty
+// accepts any prefix. It is ignored by the other backends.
+func Parse(backend loader.Backend, network string, address string) (*Address, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("empty address")
+	}
+
+	var result *Address
+	var err error
+	switch backend {
+	case loader.EthereumBackend, loader.ZksliteBackend:
+		result, err = parseEvm(address)
+	case loader.StarknetBackend:
+		result, err = parseStarknet(address)
+	case loader.SolanaBackend:
+		result, err = parseSolana(address)
+	case loader.BitcoinBackend:
+		result, err = parseBitcoin(network, address)
+	case loader.TonBackend:
+		result, err = parseTon(address)
+	case loader.CosmosBackend:
+		result, err = parseCosmos(network, address)
+	case loader.TronBackend:
+		result, err = parseTron(address)
+	case loader.NetworkTypeBfc:
+		result, err = parseBfc(address)
+	default:
+		return nil, fmt.Errorf("unsupported backend: %d", backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	result.Backend = backend
+	return result, nil
+}
+
+func Normalize(backend loader.Backend, network string, address string) (string, error) {
+	result, err := Parse(backend, network, address)
+	if err != nil {
+		return "", err
+	}
+	return result.Canonical, nil
+}
+
+func IsValid(backend loader.Backend, network string, address string) bool {
+	_, err := Parse(backend, network, address)
+	return err == nil
+}
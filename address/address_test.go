@@ -0,0 +1,101 @@
+package address
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/gagliardetto/solana-go"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cosmosData, err := bech32.ConvertBits(make([]byte, 20), 8, 5, true)
+	require.NoError(t, err)
+	cosmosAddr, err := bech32.Encode("osmo", cosmosData)
+	require.NoError(t, err)
+	pda, _, err := solana.FindProgramAddress([][]byte{[]byte("seed")}, solana.SystemProgramID)
+	require.NoError(t, err)
+	wallet := solana.NewWallet().PublicKey()
+
+	tests := []struct {
+		backend   loader.Backend
+		network   string
+		address   string
+		addrType  Type
+		canonical string
+	}{
+		{loader.EthereumBackend, "", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", TypeEvm, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{loader.EthereumBackend, "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", TypeEvm, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{loader.StarknetBackend, "", "0x49d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7", TypeStarknet, "0x049D36570D4e46f48e99674bd3fcc84644DdD6b96F7C741B1562B82f9e004dC7"},
+		{loader.SolanaBackend, "", wallet.String(), TypeSolana, wallet.String()},
+		{loader.SolanaBackend, "", pda.String(), TypeSolanaPda, pda.String()},
+		{loader.BitcoinBackend, "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", TypeP2PKH, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{loader.BitcoinBackend, BitcoinMainnet, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", TypeP2SH, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		{loader.BitcoinBackend, FractalMainnet, "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", TypeP2WPKH, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{loader.BitcoinBackend, "", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", TypeP2TR, "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297"},
+		{loader.BitcoinBackend, BitcoinTestnet4, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", TypeP2WPKH, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{loader.TonBackend, "", "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N", TypeTonBounceable, "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"},
+		{loader.TonBackend, "", "0:83DFD552E63729B472FCBCC8C45EBCC6691702558B68EC7527E1BA403A0F31A8", TypeTonRaw, "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"},
+		{loader.CosmosBackend, "osmo", cosmosAddr, TypeCosmos, cosmosAddr},
+		{loader.TronBackend, "", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", TypeTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{loader.TronBackend, "", "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", TypeTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{loader.NetworkTypeBfc, "", "BFC7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e363d", TypeBfc, "0x7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e"},
+		{loader.NetworkTypeBfc, "", "0x2", TypeBfc, "0x0000000000000000000000000000000000000000000000000000000000000002"},
+	}
+	for _, test := range tests {
+		result, err := Parse(test.backend, test.network, test.address)
+		if assert.NoError(t, err, test.address) {
+			assert.Equal(t, test.addrType, result.Type, test.address)
+			assert.Equal(t, test.canonical, result.Canonical, test.address)
+		}
+	}
+
+	invalid := []struct {
+		backend loader.Backend
+		network string
+		address string
+	}{
+		{loader.EthereumBackend, "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+		{loader.EthereumBackend, "", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{loader.StarknetBackend, "", "0x0800000000000000000000000000000000000000000000000000000000000000"},
+		{loader.SolanaBackend, "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{loader.BitcoinBackend, BitcoinTestnet, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{loader.BitcoinBackend, "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr"},
+		{loader.TonBackend, "", "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2M"},
+		{loader.CosmosBackend, "cosmos", cosmosAddr},
+		{loader.TronBackend, "", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u"},
+		{loader.TronBackend, "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{loader.TronBackend, "", "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c"},
+		{loader.TronBackend, "", "a614f803b6fd780986a42c78ec9c7f77e6ded13c"},
+		{loader.NetworkTypeBfc, "", "BFC7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e363e"},
+		{loader.NetworkTypeBfc, "", "7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e"},
+	}
+	for _, test := range invalid {
+		assert.False(t, IsValid(test.backend, test.network, test.address), test.address)
+	}
+}
+
+func TestTronConvert(t *testing.T) {
+	hexAddr, err := TronBase58ToHex("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+	require.NoError(t, err)
+	assert.Equal(t, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", hexAddr)
+
+	evmAddr, err := TronToEvm("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+	require.NoError(t, err)
+	assert.Equal(t, "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c", evmAddr)
+
+	base58Addr, err := TronHexToBase58(evmAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", base58Addr)
+
+	_, err = TronHexToBase58("0x1234")
+	assert.Error(t, err)
+}
+
+func TestBfcConvert(t *testing.T) {
+	bfcAddr, err := BfcHexToPrefixed("0x7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e")
+	require.NoError(t, err)
+	assert.Equal(t, "BFC7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e363d", bfcAddr)
+}
@@ -0,0 +1,59 @@
+package address
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// bfcPrefix starts the user facing form: the 64 hex chars account and a 4 hex chars checksum
+const bfcPrefix = "BFC"
+
+// bfcChecksum is the first 4 hex chars of the sha256 of the lowercase hex account
+func bfcChecksum(hexAddr string) string {
+	sum := sha256.Sum256([]byte(hexAddr))
+	return hex.EncodeToString(sum[:2])
+}
+
+// parseBfc accepts the BFC prefixed form with a valid checksum and the 0x hex form, both
+// canonicalize to the 0x form padded to 32 bytes that the rpc expects
+func parseBfc(address string) (*Address, error) {
+	var hexAddr string
+	switch {
+	case strings.HasPrefix(address, bfcPrefix):
+		if len(address) != len(bfcPrefix)+64+4 {
+			return nil, errors.New("not a bfc account")
+		}
+		hexAddr = strings.ToLower(address[len(bfcPrefix) : len(bfcPrefix)+64])
+		if _, err := hex.DecodeString(hexAddr); err != nil {
+			return nil, errors.New("not a hex string")
+		}
+		if !strings.EqualFold(address[len(bfcPrefix)+64:], bfcChecksum(hexAddr)) {
+			return nil, errors.New("bad checksum")
+		}
+	case strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X"):
+		hexAddr = strings.ToLower(address[2:])
+		if len(hexAddr) == 0 || len(hexAddr) > 64 {
+			return nil, errors.New("not a bfc account")
+		}
+		hexAddr = strings.Repeat("0", 64-len(hexAddr)) + hexAddr
+		if _, err := hex.DecodeString(hexAddr); err != nil {
+			return nil, errors.New("not a hex string")
+		}
+	default:
+		return nil, errors.New("missing BFC or 0x prefix")
+	}
+	return &Address{Type: TypeBfc, Canonical: "0x" + hexAddr}, nil
+}
+
+// BfcHexToPrefixed converts the 0x form to the BFC prefixed form with its checksum
+func BfcHexToPrefixed(address string) (string, error) {
+	result, err := parseBfc(strings.TrimSpace(address))
+	if err != nil {
+		return "", fmt.Errorf("invalid bfc address %s: %w", address, err)
+	}
+	hexAddr := result.Canonical[2:]
+	return bfcPrefix + hexAddr + bfcChecksum(hexAddr), nil
+}
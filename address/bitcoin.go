@@ -0,0 +1,69 @@
+package address
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	BitcoinMainnet  = "mainnet"
+	BitcoinTestnet  = "testnet"
+	BitcoinTestnet4 = "testnet4"
+	BitcoinSignet   = "signet"
+	BitcoinRegtest  = "regtest"
+	// fractal reuses the bitcoin mainnet address encoding on both of its networks
+	FractalMainnet = "fractal"
+	FractalTestnet = "fractal-testnet"
+)
+
+// GetBitcoinParams maps a network name to its address params, empty is mainnet
+func GetBitcoinParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "", BitcoinMainnet, FractalMainnet, FractalTestnet:
+		return &chaincfg.MainNetParams, nil
+	case BitcoinTestnet, BitcoinTestnet4:
+		return &chaincfg.TestNet3Params, nil
+	case BitcoinSignet:
+		return &chaincfg.SigNetParams, nil
+	case BitcoinRegtest:
+		return &chaincfg.RegressionNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown bitcoin network: %s", network)
+}
+
+func parseBitcoin(network string, address string) (*Address, error) {
+	params, err := GetBitcoinParams(network)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(params) {
+		return nil, fmt.Errorf("not a %s address", params.Name)
+	}
+
+	var addrType Type
+	switch decoded.(type) {
+	case *btcutil.AddressPubKeyHash:
+		addrType = TypeP2PKH
+	case *btcutil.AddressScriptHash:
+		addrType = TypeP2SH
+	case *btcutil.AddressWitnessPubKeyHash:
+		addrType = TypeP2WPKH
+	case *btcutil.AddressWitnessScriptHash:
+		addrType = TypeP2WSH
+	case *btcutil.AddressTaproot:
+		addrType = TypeP2TR
+	default:
+		// DecodeAddress also accepts raw public keys and unknown witness versions
+		return nil, fmt.Errorf("unsupported address type %T", decoded)
+	}
+	if network == "" {
+		network = BitcoinMainnet
+	}
+	return &Address{Type: addrType, Canonical: decoded.EncodeAddress(), Network: network}, nil
+}
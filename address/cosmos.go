@@ -0,0 +1,27 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// parseCosmos accepts account (20 bytes) and module or contract (32 bytes) addresses
+func parseCosmos(prefix string, address string) (*Address, error) {
+	hrp, data, err := bech32.Decode(address)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" && hrp != prefix {
+		return nil, fmt.Errorf("prefix %s, expected %s", hrp, prefix)
+	}
+	payload, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 20 && len(payload) != 32 {
+		return nil, fmt.Errorf("bad payload length %d", len(payload))
+	}
+	return &Address{Type: TypeCosmos, Canonical: strings.ToLower(address), Network: hrp}, nil
+}
@@ -0,0 +1,48 @@
+package address
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+// starknetAddressBound is 2^251, contract addresses are below it
+var starknetAddressBound = new(big.Int).Lsh(big.NewInt(1), 251)
+
+func parseEvm(address string) (*Address, error) {
+	if !common.IsHexAddress(address) || !strings.HasPrefix(strings.ToLower(address), "0x") {
+		return nil, errors.New("not a 20 bytes hex address")
+	}
+	checksum := common.HexToAddress(address).Hex()
+	// mixed case addresses carry an EIP-55 checksum
+	hex := address[2:]
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && address[2:] != checksum[2:] {
+		return nil, errors.New("bad checksum")
+	}
+	return &Address{Type: TypeEvm, Canonical: checksum}, nil
+}
+
+func parseStarknet(address string) (*Address, error) {
+	if !strings.HasPrefix(address, "0x") && !strings.HasPrefix(address, "0X") {
+		return nil, errors.New("missing 0x prefix")
+	}
+	hex := address[2:]
+	if len(hex) == 0 || len(hex) > 64 {
+		return nil, errors.New("not a felt")
+	}
+	value, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		return nil, errors.New("not a hex string")
+	}
+	if value.Cmp(starknetAddressBound) >= 0 {
+		return nil, errors.New("out of the address range")
+	}
+	checksum, err := util.GetChecksumAddress64(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Address{Type: TypeStarknet, Canonical: checksum}, nil
+}
@@ -0,0 +1,17 @@
+package address
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+func parseSolana(address string) (*Address, error) {
+	pubkey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return nil, err
+	}
+	result := &Address{Type: TypeSolana, Canonical: pubkey.String()}
+	if !pubkey.IsOnCurve() {
+		result.Type = TypeSolanaPda
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package address
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	tonBounceableTag    = 0x11
+	tonNonBounceableTag = 0x51
+	tonTestnetFlag      = 0x80
+)
+
+// parseTon accepts the raw workchain:hex form and the 48 chars user friendly form,
+// both canonicalize to the raw form since the friendly flags do not change the account.
+func parseTon(address string) (*Address, error) {
+	if strings.Contains(address, ":") {
+		return parseTonRaw(address)
+	}
+	if len(address) != 48 {
+		return nil, errors.New("not a user friendly address")
+	}
+	data, err := base64.URLEncoding.DecodeString(strings.NewReplacer("+", "-", "/", "_").Replace(address))
+	if err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint16(data[34:]) != crc16(data[:34]) {
+		return nil, errors.New("bad checksum")
+	}
+
+	result := &Address{Canonical: fmt.Sprintf("%d:%s", int8(data[1]), hex.EncodeToString(data[2:34]))}
+	tag := data[0]
+	if tag&tonTestnetFlag != 0 {
+		result.Network = "testnet"
+		tag &^= tonTestnetFlag
+	}
+	switch tag {
+	case tonBounceableTag:
+		result.Type = TypeTonBounceable
+	case tonNonBounceableTag:
+		result.Type = TypeTonNonBounceable
+	default:
+		return nil, fmt.Errorf("unknown tag %x", data[0])
+	}
+	return result, nil
+}
+
+func parseTonRaw(address string) (*Address, error) {
+	parts := strings.SplitN(address, ":", 2)
+	workchain, err := strconv.ParseInt(parts[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("bad workchain: %w", err)
+	}
+	hash, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 32 {
+		return nil, errors.New("not a 32 bytes account id")
+	}
+	return &Address{Type: TypeTonRaw, Canonical: fmt.Sprintf("%d:%s", workchain, hex.EncodeToString(hash))}, nil
+}
+
+// crc16 is CRC-16/XMODEM
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
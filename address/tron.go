@@ -0,0 +1,74 @@
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// tronPrefix is the version byte of tron addresses, the base58 form starts with T
+const tronPrefix = 0x41
+
+// parseTron accepts the base58check form and the 41 prefixed hex form, both canonicalize to base58.
+// A 20 bytes evm address is rejected, a receiver pasted from an evm chain must not silently turn
+// into a tron account, callers convert it explicitly with TronHexToBase58.
+func parseTron(address string) (*Address, error) {
+	if len(address) == 2*21 && strings.HasPrefix(address, "41") {
+		base58Addr, err := TronHexToBase58(address)
+		if err != nil {
+			return nil, err
+		}
+		address = base58Addr
+	}
+	payload, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return nil, err
+	}
+	if version != tronPrefix || len(payload) != 20 {
+		return nil, errors.New("not a tron account")
+	}
+	return &Address{Type: TypeTron, Canonical: address}, nil
+}
+
+// TronBase58ToHex converts a base58 address to the 41 prefixed hex form of the tron http api
+func TronBase58ToHex(address string) (string, error) {
+	payload, version, err := base58.CheckDecode(strings.TrimSpace(address))
+	if err != nil {
+		return "", fmt.Errorf("invalid tron address %s: %w", address, err)
+	}
+	if version != tronPrefix || len(payload) != 20 {
+		return "", fmt.Errorf("invalid tron address %s", address)
+	}
+	return hex.EncodeToString(append([]byte{tronPrefix}, payload...)), nil
+}
+
+// TronHexToBase58 accepts the 41 prefixed hex form and the 20 bytes evm form, with or without 0x
+func TronHexToBase58(address string) (string, error) {
+	hexAddr := strings.TrimSpace(address)
+	if strings.HasPrefix(hexAddr, "0x") || strings.HasPrefix(hexAddr, "0X") {
+		hexAddr = hexAddr[2:]
+	}
+	data, err := hex.DecodeString(hexAddr)
+	if err != nil {
+		return "", fmt.Errorf("invalid tron address %s: %w", address, err)
+	}
+	if len(data) == 21 && data[0] == tronPrefix {
+		data = data[1:]
+	}
+	if len(data) != 20 {
+		return "", fmt.Errorf("invalid tron address %s", address)
+	}
+	return base58.CheckEncode(data, tronPrefix), nil
+}
+
+// TronToEvm is the 0x form of the 20 bytes account, used in abi encoded parameters
+func TronToEvm(address string) (string, error) {
+	hexAddr, err := TronBase58ToHex(address)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hexAddr[2:], nil
+}
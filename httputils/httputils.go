@@ -96,6 +96,18 @@ func (c *Client) DoPost(ctx context.Context, urlStr string, data interface{}, he
 	return nil
 }
 
+// StatusError is returned for responses other than 200 OK, Body holds the start of the
+// response body so callers can read the error the server sent.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return "failed with status: " + e.Status
+}
+
 // Helper function for executing HTTP requests and handling responses.
 func (c *Client) doRequest(req *http.Request, response interface{}) error {
 	resp, err := c.httpClient.Do(req)
@@ -105,7 +117,8 @@ func (c *Client) doRequest(req *http.Request, response interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("failed with status: " + resp.Status)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -0,0 +1,43 @@
+package loader
+
+import (
+	"strings"
+	"sync"
+)
+
+// ChainConfigs holds a config per chain, keyed by the case insensitive chain name. Backends
+// register the extra settings their rpc and txn builders need beside ChainInfo.
+type ChainConfigs[T any] struct {
+	configs map[string]T
+	mutex   *sync.RWMutex
+}
+
+func NewChainConfigs[T any]() *ChainConfigs[T] {
+	return &ChainConfigs[T]{
+		configs: make(map[string]T),
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+func chainConfigKey(chainName string) string {
+	return strings.ToLower(strings.TrimSpace(chainName))
+}
+
+func (c *ChainConfigs[T]) Set(chainName string, cfg T) {
+	c.mutex.Lock()
+	c.configs[chainConfigKey(chainName)] = cfg
+	c.mutex.Unlock()
+}
+
+// Get returns the zero config for a chain never set
+func (c *ChainConfigs[T]) Get(chainName string) T {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.configs[chainConfigKey(chainName)]
+}
+
+func (c *ChainConfigs[T]) Delete(chainName string) {
+	c.mutex.Lock()
+	delete(c.configs, chainConfigKey(chainName))
+	c.mutex.Unlock()
+}
@@ -6,12 +6,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/ethereum/go-ethereum/ethclient"
 	ethrpc "github.com/ethereum/go-ethereum/rpc"
 	solrpc "github.com/gagliardetto/solana-go/rpc"
 	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/httputils"
 )
 
 type Backend int32
@@ -25,6 +27,7 @@ const (
 	TonBackend
 	CosmosBackend
 	NetworkTypeBfc
+	TronBackend
 )
 
 type ChainInfo struct {
@@ -128,6 +131,32 @@ func (mgr *ChainInfoManager) GetChainInfoByNetcode(netcode int32) (*ChainInfo, b
 	return chain, ok
 }
 
+// AddChainInfo registers a chain beside the loaded ones, replacing the chain of the same id.
+// The next LoadAllChains drops it.
+func (mgr *ChainInfoManager) AddChainInfo(chain *ChainInfo) {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+
+	allChains := make([]*ChainInfo, 0, len(mgr.allChains)+1)
+	if old, ok := mgr.idChains[chain.Id]; ok {
+		delete(mgr.chainIdChains, strings.ToLower(old.ChainId))
+		delete(mgr.nameChains, strings.ToLower(old.Name))
+		delete(mgr.netcodeChains, old.NetworkCode)
+		for _, c := range mgr.allChains {
+			if c != old {
+				allChains = append(allChains, c)
+			}
+		}
+	} else {
+		allChains = append(allChains, mgr.allChains...)
+	}
+	mgr.idChains[chain.Id] = chain
+	mgr.chainIdChains[strings.ToLower(chain.ChainId)] = chain
+	mgr.nameChains[strings.ToLower(chain.Name)] = chain
+	mgr.netcodeChains[chain.NetworkCode] = chain
+	mgr.allChains = append(allChains, chain)
+}
+
 func (mgr *ChainInfoManager) GetAllChains() []*ChainInfo {
 	return mgr.allChains
 }
@@ -188,6 +217,14 @@ func (mgr *ChainInfoManager) LoadAllChains() {
 				chain.Client = rpc.NewProvider(erpc)
 			} else if chain.Backend == SolanaBackend {
 				chain.Client = solrpc.New(chain.RpcEndPoint)
+			} else if chain.Backend == NetworkTypeBfc {
+				chain.Client, err = ethrpc.Dial(chain.RpcEndPoint)
+				if err != nil {
+					mgr.alerter.AlertText("create bfc client error", err)
+					continue
+				}
+			} else if chain.Backend == TonBackend || chain.Backend == CosmosBackend || chain.Backend == TronBackend {
+				chain.Client = httputils.NewClient(15 * time.Second)
 			}
 
 			idChains[chain.Id] = &chain
@@ -2,6 +2,7 @@ package network
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,10 @@ import (
 )
 
 func Request(url string, data interface{}, result interface{}) error {
+	return RequestWithContext(context.Background(), url, data, result)
+}
+
+func RequestWithContext(ctx context.Context, url string, data interface{}, result interface{}) error {
 
 	// Create a new HTTP client
 	client := http.Client{}
@@ -24,7 +29,7 @@ func Request(url string, data interface{}, result interface{}) error {
 	}
 
 	// Create a new HTTP request
-	req, err := http.NewRequest(method, url, bytes.NewReader(dataBytes))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(dataBytes))
 	if err != nil {
 		return fmt.Errorf("error creating request %v : %v", url, err)
 	}
@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+// BfcCoinType is the gas coin, which the zero token address stands for
+const BfcCoinType = "0x2::bfc::BFC"
+
+// bfcMaxCoinPages bounds the coin listing of an owner with a huge number of coin objects
+const bfcMaxCoinPages = 10
+
+type BfcBalance struct {
+	CoinType        string `json:"coinType"`
+	CoinObjectCount int64  `json:"coinObjectCount"`
+	TotalBalance    string `json:"totalBalance"`
+}
+
+type BfcCoinMetadata struct {
+	Decimals    int32  `json:"decimals"`
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Description string `json:"description"`
+	IconUrl     string `json:"iconUrl"`
+}
+
+type BfcCoin struct {
+	CoinType     string `json:"coinType"`
+	CoinObjectId string `json:"coinObjectId"`
+	Version      string `json:"version"`
+	Digest       string `json:"digest"`
+	Balance      string `json:"balance"`
+}
+
+type bfcCoinPage struct {
+	Data        []BfcCoin `json:"data"`
+	NextCursor  *string   `json:"nextCursor"`
+	HasNextPage bool      `json:"hasNextPage"`
+}
+
+type bfcSupply struct {
+	Value string `json:"value"`
+}
+
+type BfcTransactionBlock struct {
+	Digest  string `json:"digest"`
+	Effects *struct {
+		Status struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"status"`
+		GasUsed struct {
+			ComputationCost string `json:"computationCost"`
+			StorageCost     string `json:"storageCost"`
+			StorageRebate   string `json:"storageRebate"`
+		} `json:"gasUsed"`
+	} `json:"effects"`
+	Checkpoint  string `json:"checkpoint"`
+	TimestampMs string `json:"timestampMs"`
+}
+
+// BfcRpc reads a bfc chain, a sui family chain, through its json rpc. Tokens are coin types
+// like 0x2::bfc::BFC and balances the sum of the coin objects of the owner.
+type BfcRpc struct {
+	tokenInfoMgr *loader.TokenInfoManager
+	chainInfo    *loader.ChainInfo
+}
+
+func NewBfcRpc(chainInfo *loader.ChainInfo) *BfcRpc {
+	return &BfcRpc{
+		chainInfo:    chainInfo,
+		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
+	}
+}
+
+func (w *BfcRpc) GetClient() *ethrpc.Client {
+	return w.chainInfo.Client.(*ethrpc.Client)
+}
+
+func (w *BfcRpc) Client() interface{} {
+	return w.chainInfo.Client
+}
+
+func (w *BfcRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
+func (w *BfcRpc) Backend() int32 {
+	return 8
+}
+
+// BfcHexAddress turns the BFC prefixed form, the hex account followed by a 4 hex chars
+// checksum, into the 0x form, failing on a bad checksum
+func BfcHexAddress(addr string) (string, error) {
+	return address.Normalize(loader.NetworkTypeBfc, "", addr)
+}
+
+// BfcCoinTypeOf resolves the zero token address to the gas coin type
+func BfcCoinTypeOf(tokenAddr string) string {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return BfcCoinType
+	}
+	return tokenAddr
+}
+
+func (w *BfcRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	var checkpoint string
+	err := w.GetClient().CallContext(ctx, &checkpoint, "bfc_getLatestCheckpointSequenceNumber")
+	var seq int64
+	if err == nil {
+		seq, err = strconv.ParseInt(checkpoint, 10, 64)
+	}
+	if err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return seq, nil
+}
+
+func (w *BfcRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	tokenInfo, ok := w.tokenInfoMgr.GetByChainNameTokenAddr(w.chainInfo.Name, tokenAddr)
+	if ok {
+		return *tokenInfo, nil
+	}
+
+	var metadata *BfcCoinMetadata
+	if err := w.GetClient().CallContext(ctx, &metadata, "bfcx_getCoinMetadata", tokenAddr); err != nil {
+		return loader.TokenInfo{}, err
+	}
+	if metadata == nil || metadata.Symbol == "" {
+		return loader.TokenInfo{}, fmt.Errorf("not found")
+	}
+
+	totalSupply := big.NewInt(0)
+	var supply bfcSupply
+	if err := w.GetClient().CallContext(ctx, &supply, "bfcx_getTotalSupply", tokenAddr); err == nil {
+		if value, ok := new(big.Int).SetString(supply.Value, 10); ok {
+			totalSupply = value
+		}
+	}
+
+	ti := loader.TokenInfo{
+		TokenName:    metadata.Symbol,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     metadata.Decimals,
+		FullName:     metadata.Name,
+		TotalSupply:  totalSupply,
+	}
+	w.tokenInfoMgr.AddTokenInfo(ti)
+	return ti, nil
+}
+
+func (w *BfcRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	return w.GetBalance(ctx, ownerAddr, tokenAddr)
+}
+
+func (w *BfcRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	owner, err := BfcHexAddress(ownerAddr)
+	if err != nil {
+		return nil, err
+	}
+	var balance BfcBalance
+	if err := w.GetClient().CallContext(ctx, &balance, "bfcx_getBalance", owner, BfcCoinTypeOf(tokenAddr)); err != nil {
+		return nil, err
+	}
+	value, ok := new(big.Int).SetString(balance.TotalBalance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s of %s", balance.TotalBalance, ownerAddr)
+	}
+	return value, nil
+}
+
+// GetCoins lists the coin objects of the owner, at most bfcMaxCoinPages pages
+func (w *BfcRpc) GetCoins(ctx context.Context, ownerAddr string, tokenAddr string) ([]BfcCoin, error) {
+	ownerAddr, err := BfcHexAddress(ownerAddr)
+	if err != nil {
+		return nil, err
+	}
+	coinType := BfcCoinTypeOf(tokenAddr)
+
+	var coins []BfcCoin
+	var cursor *string
+	for i := 0; i < bfcMaxCoinPages; i++ {
+		var page bfcCoinPage
+		if err := w.GetClient().CallContext(ctx, &page, "bfcx_getCoins", ownerAddr, coinType, cursor, nil); err != nil {
+			return nil, err
+		}
+		coins = append(coins, page.Data...)
+		if !page.HasNextPage || page.NextCursor == nil {
+			return coins, nil
+		}
+		cursor = page.NextCursor
+	}
+	log.Warnf("%v %v has more than %d pages of %v coins", w.chainInfo.Name, ownerAddr, bfcMaxCoinPages, coinType)
+	return coins, nil
+}
+
+func (w *BfcRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	return big.NewInt(0), fmt.Errorf("not impl")
+}
+
+// GetTransactionBlock is nil when the digest is unknown
+func (w *BfcRpc) GetTransactionBlock(ctx context.Context, digest string) (*BfcTransactionBlock, error) {
+	var tx BfcTransactionBlock
+	options := map[string]bool{"showEffects": true}
+	if err := w.GetClient().CallContext(ctx, &tx, "bfc_getTransactionBlock", strings.TrimSpace(digest), options); err != nil {
+		if isNotFoundError(err) || strings.Contains(strings.ToLower(err.Error()), "could not find") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (w *BfcRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus takes the status from the effects, which are final once known. BlockNumber is
+// the checkpoint, 0 while the tx is not checkpointed yet.
+func (w *BfcRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	hash = strings.TrimSpace(hash)
+	tx, err := w.GetTransactionBlock(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.Effects == nil {
+		return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+	}
+
+	status := &TxStatus{Hash: hash, State: TxStateSuccess}
+	status.BlockNumber, _ = strconv.ParseInt(tx.Checkpoint, 10, 64)
+	if timestamp, err := strconv.ParseInt(tx.TimestampMs, 10, 64); err == nil {
+		status.BlockTime = timestamp / 1000
+	}
+	gasUsed := tx.Effects.GasUsed
+	fee := new(big.Int)
+	for _, cost := range []string{gasUsed.ComputationCost, gasUsed.StorageCost} {
+		if value, ok := new(big.Int).SetString(cost, 10); ok {
+			fee.Add(fee, value)
+		}
+	}
+	if rebate, ok := new(big.Int).SetString(gasUsed.StorageRebate, 10); ok {
+		fee.Sub(fee, rebate)
+	}
+	status.Fee = fee
+
+	if tx.Effects.Status.Status != "success" {
+		status.State = TxStateReverted
+		status.RevertReason = tx.Effects.Status.Error
+	}
+	return status, nil
+}
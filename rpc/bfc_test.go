@@ -0,0 +1,129 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBfcOwner = "0x7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e"
+
+func TestBfcRpc(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		var rpcErr interface{}
+		switch req.Method {
+		case "bfc_getLatestCheckpointSequenceNumber":
+			result = "3000000"
+		case "bfcx_getBalance":
+			assert.JSONEq(t, `"`+testBfcOwner+`"`, string(req.Params[0]))
+			if string(req.Params[1]) == `"`+BfcCoinType+`"` {
+				result = map[string]interface{}{"coinType": BfcCoinType, "coinObjectCount": 2, "totalBalance": "5000000000"}
+			} else {
+				result = map[string]interface{}{"coinType": "0xabc::busd::BUSD", "coinObjectCount": 0, "totalBalance": "0"}
+			}
+		case "bfcx_getCoinMetadata":
+			if string(req.Params[0]) == `"0xabc::busd::BUSD"` {
+				result = map[string]interface{}{"decimals": 9, "name": "Benfen USD", "symbol": "BUSD"}
+			}
+		case "bfcx_getTotalSupply":
+			result = map[string]interface{}{"value": "1000000"}
+		case "bfcx_getCoins":
+			if string(req.Params[2]) == "null" {
+				result = map[string]interface{}{"data": []map[string]string{{"coinObjectId": "0x1", "balance": "10"}}, "nextCursor": "0x1", "hasNextPage": true}
+			} else {
+				result = map[string]interface{}{"data": []map[string]string{{"coinObjectId": "0x2", "balance": "20"}}, "nextCursor": nil, "hasNextPage": false}
+			}
+		case "bfc_getTransactionBlock":
+			switch string(req.Params[0]) {
+			case `"success"`:
+				result = map[string]interface{}{"digest": "success", "checkpoint": "2999999", "timestampMs": "1700000000000",
+					"effects": map[string]interface{}{"status": map[string]string{"status": "success"},
+						"gasUsed": map[string]string{"computationCost": "1000", "storageCost": "2000", "storageRebate": "500"}}}
+			case `"failure"`:
+				result = map[string]interface{}{"digest": "failure", "checkpoint": "2999998",
+					"effects": map[string]interface{}{"status": map[string]string{"status": "failure", "error": "InsufficientCoinBalance"},
+						"gasUsed": map[string]string{"computationCost": "1000", "storageCost": "0", "storageRebate": "0"}}}
+			default:
+				rpcErr = map[string]interface{}{"code": -32602, "message": "Could not find the referenced transaction"}
+			}
+		default:
+			t.Fatalf("unexpected method %s", req.Method)
+		}
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+		if rpcErr != nil {
+			rsp["error"] = rpcErr
+		} else {
+			rsp["result"] = result
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+
+	client, err := ethrpc.Dial(server.URL)
+	require.NoError(t, err)
+	chainInfo := &loader.ChainInfo{Name: "BfcMainnet", Backend: loader.NetworkTypeBfc, Client: client, GasTokenName: "BFC", GasTokenDecimal: 9}
+	w, err := GetRpc(chainInfo)
+	require.NoError(t, err)
+	bfcRpc := w.(*BfcRpc)
+
+	checkpoint, err := bfcRpc.GetLatestBlockNumber(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3000000), checkpoint)
+
+	balance, err := bfcRpc.GetBalance(context.TODO(), "BFC"+testBfcOwner[2:]+"363d", "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5000000000), balance)
+
+	_, err = bfcRpc.GetBalance(context.TODO(), "BFC"+testBfcOwner[2:]+"363e", "0x0")
+	assert.ErrorContains(t, err, "bad checksum")
+
+	balance, err = bfcRpc.GetBalance(context.TODO(), testBfcOwner, "0xabc::busd::BUSD")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), balance)
+
+	info, err := bfcRpc.GetTokenInfo(context.TODO(), "0xabc::busd::BUSD")
+	assert.NoError(t, err)
+	assert.Equal(t, "BUSD", info.TokenName)
+	assert.Equal(t, int32(9), info.Decimals)
+	assert.Equal(t, big.NewInt(1000000), info.TotalSupply)
+
+	_, err = bfcRpc.GetTokenInfo(context.TODO(), "0xdef::none::NONE")
+	assert.Error(t, err)
+
+	coins, err := bfcRpc.GetCoins(context.TODO(), testBfcOwner, "0x0")
+	assert.NoError(t, err)
+	assert.Len(t, coins, 2)
+
+	ok, block, err := bfcRpc.IsTxSuccess(context.TODO(), "success")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2999999), block)
+
+	status, err := bfcRpc.GetTxStatus(context.TODO(), "success")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2500), status.Fee)
+	assert.Equal(t, int64(1700000000), status.BlockTime)
+
+	status, err = bfcRpc.GetTxStatus(context.TODO(), "failure")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateReverted, status.State)
+	assert.Equal(t, "InsufficientCoinBalance", status.RevertReason)
+
+	_, _, err = bfcRpc.IsTxSuccess(context.TODO(), "unknown")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
@@ -6,25 +6,189 @@ import (
 	"math/big"
 	"strings"
 
-	_ "github.com/gagliardetto/solana-go"
-	"github.com/ninja0404/go-unisat"
 	"github.com/owlto-dao/utils-go/loader"
-	"github.com/owlto-dao/utils-go/owlconsts"
+	"github.com/owlto-dao/utils-go/log"
 	"github.com/owlto-dao/utils-go/util"
 )
 
+const (
+	BitcoinProviderEsplora = "esplora"
+	BitcoinProviderCore    = "core"
+)
+
+type BitcoinUtxo struct {
+	TxId         string
+	Vout         uint32
+	Value        int64
+	ScriptPubKey string
+	Confirmed    bool
+	BlockHeight  int64
+}
+
+type BitcoinTxStatus struct {
+	Confirmed     bool
+	BlockHeight   int64
+	BlockHash     string
+	BlockTime     int64
+	Confirmations int64
+}
+
+type Brc20Info struct {
+	Ticker      string
+	Decimals    int32
+	Max         string
+	TotalMinted string
+}
+
+// BitcoinProvider is the data source behind BitcoinRpc.
+type BitcoinProvider interface {
+	GetBlockHeight(ctx context.Context) (int64, error)
+	GetBalance(ctx context.Context, addr string) (*big.Int, error)
+	ListUtxos(ctx context.Context, addr string) ([]BitcoinUtxo, error)
+	GetTxStatus(ctx context.Context, hash string) (*BitcoinTxStatus, error)
+}
+
+// Brc20Provider is an optional indexer used for brc20_ prefixed tokens.
+type Brc20Provider interface {
+	GetBrc20Balance(ctx context.Context, addr string, ticker string) (*big.Int, error)
+	GetBrc20Info(ctx context.Context, ticker string) (*Brc20Info, error)
+}
+
+// BitcoinConfig describes how to reach a bitcoin chain. Provider is one of "esplora" or
+// "core" and has no default, RpcEndPoint falls back to ChainInfo.RpcEndPoint.
+type BitcoinConfig struct {
+	Provider         string `mapstructure:"provider"`
+	RpcEndPoint      string `mapstructure:"rpc_end_point"`
+	RpcUser          string `mapstructure:"rpc_user"`
+	RpcPassword      string `mapstructure:"rpc_password"`
+	ApiKey           string `mapstructure:"api_key"`
+	Brc20EndPoint    string `mapstructure:"brc20_end_point"`
+	Brc20Bearer      string `mapstructure:"brc20_bearer"`
+	MinConfirmations int64  `mapstructure:"min_confirmations"`
+}
+
+var bitcoinConfigs = loader.NewChainConfigs[BitcoinConfig]()
+
+func SetBitcoinConfig(chainName string, cfg BitcoinConfig) {
+	bitcoinConfigs.Set(chainName, cfg)
+}
+
+func GetBitcoinConfig(chainName string) BitcoinConfig {
+	return bitcoinConfigs.Get(chainName)
+}
+
+func NewBitcoinProvider(cfg BitcoinConfig) (BitcoinProvider, error) {
+	endpoint := strings.TrimSpace(cfg.RpcEndPoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("empty bitcoin rpc end point")
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "":
+		return nil, fmt.Errorf("bitcoin provider not configured")
+	case BitcoinProviderEsplora:
+		return NewEsploraProvider(endpoint, cfg.ApiKey), nil
+	case BitcoinProviderCore:
+		return NewBitcoinCoreProvider(endpoint, cfg.RpcUser, cfg.RpcPassword), nil
+	default:
+		return nil, fmt.Errorf("unsupport bitcoin provider: %s", cfg.Provider)
+	}
+}
+
 type BitcoinRpc struct {
-	chainInfo *loader.ChainInfo
+	chainInfo        *loader.ChainInfo
+	provider         BitcoinProvider
+	brc20Provider    Brc20Provider
+	minConfirmations int64
+}
+
+// NewBitcoinRpc uses the config registered by SetBitcoinConfig for the chain, a chain without
+// a valid provider config fails instead of reading balances from the wrong api.
+func NewBitcoinRpc(chainInfo *loader.ChainInfo) (*BitcoinRpc, error) {
+	w, err := NewBitcoinRpcFromConfig(chainInfo, GetBitcoinConfig(chainInfo.Name))
+	if err != nil {
+		return nil, fmt.Errorf("%v create bitcoin provider error: %w", chainInfo.Name, err)
+	}
+	return w, nil
+}
+
+func NewBitcoinRpcFromConfig(chainInfo *loader.ChainInfo, cfg BitcoinConfig) (*BitcoinRpc, error) {
+	if strings.TrimSpace(cfg.RpcEndPoint) == "" {
+		cfg.RpcEndPoint = chainInfo.RpcEndPoint
+	}
+	provider, err := NewBitcoinProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var brc20Provider Brc20Provider
+	if strings.TrimSpace(cfg.Brc20EndPoint) != "" {
+		brc20Provider = NewUnisatBrc20Provider(cfg.Brc20EndPoint, cfg.Brc20Bearer)
+	}
+	return NewBitcoinRpcWithProvider(chainInfo, provider, brc20Provider, cfg.MinConfirmations), nil
 }
 
-func NewBitcoinRpc(chainInfo *loader.ChainInfo) *BitcoinRpc {
+func NewBitcoinRpcWithProvider(chainInfo *loader.ChainInfo, provider BitcoinProvider, brc20Provider Brc20Provider, minConfirmations int64) *BitcoinRpc {
+	if minConfirmations <= 0 {
+		minConfirmations = 1
+	}
 	return &BitcoinRpc{
-		chainInfo: chainInfo,
+		chainInfo:        chainInfo,
+		provider:         provider,
+		brc20Provider:    brc20Provider,
+		minConfirmations: minConfirmations,
+	}
+}
+
+func (w *BitcoinRpc) Provider() BitcoinProvider {
+	return w.provider
+}
+
+func (w *BitcoinRpc) Brc20Provider() Brc20Provider {
+	return w.brc20Provider
+}
+
+func isBrc20Token(tokenAddr string) (string, bool) {
+	if strings.HasPrefix(tokenAddr, "brc20_") && len(tokenAddr) > 6 {
+		return tokenAddr[6:], true
 	}
+	return "", false
 }
 
 func (w *BitcoinRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
-	return loader.TokenInfo{}, fmt.Errorf("no impl")
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	ticker, ok := isBrc20Token(tokenAddr)
+	if !ok {
+		return loader.TokenInfo{}, fmt.Errorf("unsupport token: %s", tokenAddr)
+	}
+	if w.brc20Provider == nil {
+		return loader.TokenInfo{}, fmt.Errorf("%v brc20 provider not configured", w.chainInfo.Name)
+	}
+	info, err := w.brc20Provider.GetBrc20Info(ctx, ticker)
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	totalSupply, ok := new(big.Int).SetString(info.Max, 10)
+	if !ok {
+		totalSupply = big.NewInt(0)
+	}
+	return loader.TokenInfo{
+		TokenName:    info.Ticker,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     info.Decimals,
+		FullName:     info.Ticker,
+		TotalSupply:  totalSupply,
+	}, nil
 }
 
 func (w *BitcoinRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
@@ -36,69 +200,73 @@ func (w *BitcoinRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr
 	tokenAddr = strings.TrimSpace(tokenAddr)
 
 	if util.IsHexStringZero(tokenAddr) {
-		resp, err := unisat.GetAddressBalance(ctx, chainServerMap[w.chainInfo.Name].RpcEndPoint, chainServerMap[w.chainInfo.Name].Bearer, ownerAddr)
-		if err != nil {
-			return nil, err
+		return w.provider.GetBalance(ctx, ownerAddr)
+	} else if ticker, ok := isBrc20Token(tokenAddr); ok {
+		if w.brc20Provider == nil {
+			return nil, fmt.Errorf("%v brc20 provider not configured", w.chainInfo.Name)
 		}
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("unisat GetAddressBalance error: %v", resp.Message)
-		}
-		return resp.Data.Satoshi, nil
-	} else if strings.HasPrefix(tokenAddr, "brc20_") && len(tokenAddr) > 6 {
-		brc20 := tokenAddr[6:]
-		resp, err := unisat.GetAddressBrc20TickInfo(ctx, chainServerMap[w.chainInfo.Name].RpcEndPoint, chainServerMap[w.chainInfo.Name].Bearer, ownerAddr, brc20)
-		if err != nil {
-			return nil, err
-		}
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("unisat GetAddressBrc20TickInfo error: %v", resp.Message)
-		}
-		balance, ok := big.NewInt(0).SetString(resp.Data.OverallBalance, 10)
-		if ok {
-			return balance, nil
-		}
-		return big.NewInt(0), nil
+		return w.brc20Provider.GetBrc20Balance(ctx, ownerAddr, ticker)
 	} else {
 		return big.NewInt(0), fmt.Errorf("not impl")
 	}
 }
 
+func (w *BitcoinRpc) ListUtxos(ctx context.Context, ownerAddr string) ([]BitcoinUtxo, error) {
+	return w.provider.ListUtxos(ctx, strings.TrimSpace(ownerAddr))
+}
+
 func (w *BitcoinRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
 	return big.NewInt(0), fmt.Errorf("not impl")
 }
 
+// IsTxSuccess reports success once the tx has at least the configured number of confirmations.
 func (w *BitcoinRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	return false, 0, fmt.Errorf("not impl")
+	status, err := w.GetTxStatus(ctx, hash)
+	if err == nil && status.State == TxStateSuccess && status.Confirmations < w.minConfirmations {
+		return false, status.BlockNumber, fmt.Errorf("not complete: %d/%d confirmations", status.Confirmations, w.minConfirmations)
+	}
+	return IsTxSuccessFromStatus(status, err)
+}
+
+// GetTxStatus reports mempool txs as pending, a confirmed bitcoin tx cannot fail
+func (w *BitcoinRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	hash = strings.TrimSpace(hash)
+	txStatus, err := w.provider.GetTxStatus(ctx, hash)
+	if err != nil {
+		if isNotFoundError(err) {
+			return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+		}
+		return nil, err
+	}
+	if !txStatus.Confirmed {
+		return &TxStatus{Hash: hash, State: TxStatePending}, nil
+	}
+	return &TxStatus{
+		Hash:          hash,
+		State:         TxStateSuccess,
+		BlockNumber:   txStatus.BlockHeight,
+		BlockTime:     txStatus.BlockTime,
+		Confirmations: txStatus.Confirmations,
+	}, nil
 }
 
 func (w *BitcoinRpc) Client() interface{} {
 	return w.chainInfo.Client
 }
 
+func (w *BitcoinRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
 func (w *BitcoinRpc) Backend() int32 {
 	return 4
 }
 
 func (w *BitcoinRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
-	return 0, fmt.Errorf("not impl")
-}
-
-type unisatServer struct {
-	RpcEndPoint string
-	Bearer      string
-}
-
-var chainServerMap = map[string]unisatServer{
-	owlconsts.Bitcoin: {
-		RpcEndPoint: "https://open-api.unisat.io",
-		Bearer:      "4ec6c2086742105639c4716a57b1234144e3bbfd0727ee7692e1cfeaca964105",
-	},
-	owlconsts.FractalBitcoin: {
-		RpcEndPoint: "https://open-api-fractal.unisat.io",
-		Bearer:      "d44b54a14f41891e4b850b9d6aace056911f553a0357299620df4cb6d383af12",
-	},
-	owlconsts.FractalBitcoinTest: {
-		RpcEndPoint: "https://open-api-fractal-testnet.unisat.io",
-		Bearer:      "523c5848192152b2eb1dd20ee08128aa77b9d673812f9fbfb5eb7218518ef195",
-	},
+	blockNumber, err := w.provider.GetBlockHeight(ctx)
+	if err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return blockNumber, nil
 }
@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type bitcoinCoreRequest struct {
+	JsonRpc string        `json:"jsonrpc"`
+	Id      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type bitcoinCoreError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+type bitcoinCoreResponse struct {
+	Result json.RawMessage   `json:"result"`
+	Error  *bitcoinCoreError `json:"error"`
+}
+
+type bitcoinCoreUnspent struct {
+	TxId         string      `json:"txid"`
+	Vout         uint32      `json:"vout"`
+	ScriptPubKey string      `json:"scriptPubKey"`
+	Amount       json.Number `json:"amount"`
+	Height       int64       `json:"height"`
+}
+
+type bitcoinCoreScanResult struct {
+	Success     bool                 `json:"success"`
+	Unspents    []bitcoinCoreUnspent `json:"unspents"`
+	TotalAmount json.Number          `json:"total_amount"`
+}
+
+type bitcoinCoreRawTx struct {
+	TxId          string `json:"txid"`
+	BlockHash     string `json:"blockhash"`
+	BlockTime     int64  `json:"blocktime"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+type bitcoinCoreBlockHeader struct {
+	Hash   string `json:"hash"`
+	Height int64  `json:"height"`
+}
+
+// BitcoinCoreProvider talks to a bitcoind JSON-RPC endpoint. Address lookups use
+// scantxoutset and tx lookups need txindex enabled on the node.
+type BitcoinCoreProvider struct {
+	endpoint string
+	user     string
+	password string
+	client   *http.Client
+	id       int64
+}
+
+func NewBitcoinCoreProvider(endpoint string, user string, password string) *BitcoinCoreProvider {
+	return &BitcoinCoreProvider{
+		endpoint: strings.TrimSpace(endpoint),
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *BitcoinCoreProvider) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := json.Marshal(bitcoinCoreRequest{
+		JsonRpc: "1.0",
+		Id:      atomic.AddInt64(&p.id, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.user != "" || p.password != "" {
+		req.SetBasicAuth(p.user, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("bitcoin core %s error: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("bitcoin core %s read body error: %w", method, err)
+	}
+
+	// bitcoind answers rpc errors with a non 200 status and a json error body
+	var rsp bitcoinCoreResponse
+	if err := json.Unmarshal(body, &rsp); err != nil {
+		return fmt.Errorf("bitcoin core %s unexpected response %v: %s", method, resp.StatusCode, string(body))
+	}
+	if rsp.Error != nil {
+		return fmt.Errorf("bitcoin core %s error %d: %s", method, rsp.Error.Code, rsp.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+func (p *BitcoinCoreProvider) GetBlockHeight(ctx context.Context) (int64, error) {
+	var height int64
+	if err := p.call(ctx, "getblockcount", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+func (p *BitcoinCoreProvider) scan(ctx context.Context, addr string) (*bitcoinCoreScanResult, error) {
+	var result bitcoinCoreScanResult
+	descs := []map[string]interface{}{{"desc": "addr(" + addr + ")"}}
+	if err := p.call(ctx, "scantxoutset", &result, "start", descs); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("bitcoin core scantxoutset failed: %s", addr)
+	}
+	return &result, nil
+}
+
+func (p *BitcoinCoreProvider) GetBalance(ctx context.Context, addr string) (*big.Int, error) {
+	result, err := p.scan(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return btcToSatoshi(result.TotalAmount.String())
+}
+
+func (p *BitcoinCoreProvider) ListUtxos(ctx context.Context, addr string) ([]BitcoinUtxo, error) {
+	result, err := p.scan(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]BitcoinUtxo, 0, len(result.Unspents))
+	for _, unspent := range result.Unspents {
+		value, err := btcToSatoshi(unspent.Amount.String())
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, BitcoinUtxo{
+			TxId:         unspent.TxId,
+			Vout:         unspent.Vout,
+			Value:        value.Int64(),
+			ScriptPubKey: unspent.ScriptPubKey,
+			Confirmed:    true,
+			BlockHeight:  unspent.Height,
+		})
+	}
+	return utxos, nil
+}
+
+func (p *BitcoinCoreProvider) GetTxStatus(ctx context.Context, hash string) (*BitcoinTxStatus, error) {
+	var tx bitcoinCoreRawTx
+	if err := p.call(ctx, "getrawtransaction", &tx, hash, true); err != nil {
+		return nil, err
+	}
+	if tx.BlockHash == "" {
+		return &BitcoinTxStatus{}, nil
+	}
+
+	var header bitcoinCoreBlockHeader
+	if err := p.call(ctx, "getblockheader", &header, tx.BlockHash); err != nil {
+		return nil, err
+	}
+	return &BitcoinTxStatus{
+		Confirmed:     true,
+		BlockHeight:   header.Height,
+		BlockHash:     tx.BlockHash,
+		BlockTime:     tx.BlockTime,
+		Confirmations: tx.Confirmations,
+	}, nil
+}
+
+// btcToSatoshi parses the fixed point BTC amounts returned by bitcoind without
+// going through floating point.
+func btcToSatoshi(amount string) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	whole, frac, _ := strings.Cut(amount, ".")
+	if len(frac) > 8 {
+		return nil, fmt.Errorf("invalid btc amount: %s", amount)
+	}
+	frac += strings.Repeat("0", 8-len(frac))
+	value, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid btc amount: %s", amount)
+	}
+	return big.NewInt(value), nil
+}
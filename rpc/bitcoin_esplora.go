@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/owlto-dao/utils-go/httputils"
+)
+
+type esploraTxStatus struct {
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight int64  `json:"block_height"`
+	BlockHash   string `json:"block_hash"`
+	BlockTime   int64  `json:"block_time"`
+}
+
+type esploraStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+}
+
+type esploraAddress struct {
+	Address      string       `json:"address"`
+	ChainStats   esploraStats `json:"chain_stats"`
+	MempoolStats esploraStats `json:"mempool_stats"`
+}
+
+type esploraUtxo struct {
+	TxId   string          `json:"txid"`
+	Vout   uint32          `json:"vout"`
+	Value  int64           `json:"value"`
+	Status esploraTxStatus `json:"status"`
+}
+
+// EsploraProvider talks to an esplora compatible REST api (blockstream, mempool.space, electrs).
+type EsploraProvider struct {
+	endpoint string
+	apiKey   string
+	client   *httputils.Client
+}
+
+func NewEsploraProvider(endpoint string, apiKey string) *EsploraProvider {
+	return &EsploraProvider{
+		endpoint: strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		apiKey:   strings.TrimSpace(apiKey),
+		client:   httputils.NewClient(15 * time.Second),
+	}
+}
+
+func (p *EsploraProvider) get(ctx context.Context, path string, result interface{}) error {
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	err := p.client.DoGet(ctx, p.endpoint+path, headers, result)
+	if err != nil {
+		return fmt.Errorf("esplora get %s error: %w", path, err)
+	}
+	return nil
+}
+
+func (p *EsploraProvider) GetBlockHeight(ctx context.Context) (int64, error) {
+	var height int64
+	if err := p.get(ctx, "/blocks/tip/height", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+func (p *EsploraProvider) GetBalance(ctx context.Context, addr string) (*big.Int, error) {
+	var info esploraAddress
+	if err := p.get(ctx, "/address/"+addr, &info); err != nil {
+		return nil, err
+	}
+	balance := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum +
+		info.MempoolStats.FundedTxoSum - info.MempoolStats.SpentTxoSum
+	return big.NewInt(balance), nil
+}
+
+func (p *EsploraProvider) ListUtxos(ctx context.Context, addr string) ([]BitcoinUtxo, error) {
+	var utxos []esploraUtxo
+	if err := p.get(ctx, "/address/"+addr+"/utxo", &utxos); err != nil {
+		return nil, err
+	}
+	result := make([]BitcoinUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		result = append(result, BitcoinUtxo{
+			TxId:        utxo.TxId,
+			Vout:        utxo.Vout,
+			Value:       utxo.Value,
+			Confirmed:   utxo.Status.Confirmed,
+			BlockHeight: utxo.Status.BlockHeight,
+		})
+	}
+	return result, nil
+}
+
+func (p *EsploraProvider) GetTxStatus(ctx context.Context, hash string) (*BitcoinTxStatus, error) {
+	var status esploraTxStatus
+	if err := p.get(ctx, "/tx/"+hash+"/status", &status); err != nil {
+		return nil, err
+	}
+	result := &BitcoinTxStatus{
+		Confirmed:   status.Confirmed,
+		BlockHeight: status.BlockHeight,
+		BlockHash:   status.BlockHash,
+		BlockTime:   status.BlockTime,
+	}
+	if status.Confirmed {
+		tip, err := p.GetBlockHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result.Confirmations = tip - status.BlockHeight + 1
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEsploraProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks/tip/height", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("850010"))
+	})
+	mux.HandleFunc("/address/bc1qowner", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"chain_stats":{"funded_txo_sum":5000,"spent_txo_sum":1000},"mempool_stats":{"funded_txo_sum":200,"spent_txo_sum":0}}`))
+	})
+	mux.HandleFunc("/address/bc1qowner/utxo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"txid":"aa","vout":1,"value":4000,"status":{"confirmed":true,"block_height":850000}}]`))
+	})
+	mux.HandleFunc("/tx/confirmed/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":true,"block_height":850009,"block_hash":"bb","block_time":1}`))
+	})
+	mux.HandleFunc("/tx/mempool/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":false}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chainInfo := &loader.ChainInfo{Name: "BitcoinMainnet", RpcEndPoint: server.URL}
+	btcRpc, err := NewBitcoinRpcFromConfig(chainInfo, BitcoinConfig{Provider: BitcoinProviderEsplora, MinConfirmations: 2})
+	assert.NoError(t, err)
+
+	height, err := btcRpc.GetLatestBlockNumber(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(850010), height)
+
+	balance, err := btcRpc.GetBalance(context.TODO(), "bc1qowner", "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(4200), balance)
+
+	utxos, err := btcRpc.ListUtxos(context.TODO(), "bc1qowner")
+	assert.NoError(t, err)
+	assert.Len(t, utxos, 1)
+	assert.Equal(t, int64(4000), utxos[0].Value)
+
+	ok, block, err := btcRpc.IsTxSuccess(context.TODO(), "confirmed")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(850009), block)
+
+	_, _, err = btcRpc.IsTxSuccess(context.TODO(), "mempool")
+	assert.Error(t, err)
+
+	_, err = btcRpc.GetBalance(context.TODO(), "bc1qowner", "brc20_ordi")
+	assert.Error(t, err)
+
+	// a chain without provider config fails instead of defaulting to an api it may not serve
+	w, err := GetRpc(&loader.ChainInfo{Name: "UnconfiguredBitcoin", Backend: loader.BitcoinBackend, RpcEndPoint: "https://open-api.unisat.io"})
+	assert.ErrorContains(t, err, "bitcoin provider not configured")
+	assert.Nil(t, w)
+	_, err = NewBitcoinRpcFromConfig(chainInfo, BitcoinConfig{Provider: "unisat"})
+	assert.ErrorContains(t, err, "unsupport bitcoin provider")
+}
+
+func TestBitcoinCoreProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", password)
+
+		var req bitcoinCoreRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch req.Method {
+		case "getblockcount":
+			w.Write([]byte(`{"result":100,"error":null}`))
+		case "scantxoutset":
+			w.Write([]byte(`{"result":{"success":true,"unspents":[{"txid":"aa","vout":0,"scriptPubKey":"0014","amount":0.29000000,"height":90}],"total_amount":0.29000000},"error":null}`))
+		case "getrawtransaction":
+			w.Write([]byte(`{"result":{"txid":"aa","blockhash":"bb","blocktime":1,"confirmations":11},"error":null}`))
+		case "getblockheader":
+			w.Write([]byte(`{"result":{"hash":"bb","height":90},"error":null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewBitcoinProvider(BitcoinConfig{Provider: BitcoinProviderCore, RpcEndPoint: server.URL, RpcUser: "user", RpcPassword: "pass"})
+	assert.NoError(t, err)
+
+	height, err := provider.GetBlockHeight(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), height)
+
+	balance, err := provider.GetBalance(context.TODO(), "bc1qowner")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(29000000), balance)
+
+	status, err := provider.GetTxStatus(context.TODO(), "aa")
+	assert.NoError(t, err)
+	assert.True(t, status.Confirmed)
+	assert.Equal(t, int64(90), status.BlockHeight)
+	assert.Equal(t, int64(11), status.Confirmations)
+}
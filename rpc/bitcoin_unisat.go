@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ninja0404/go-unisat"
+)
+
+type UnisatBrc20Provider struct {
+	endpoint string
+	bearer   string
+}
+
+func NewUnisatBrc20Provider(endpoint string, bearer string) *UnisatBrc20Provider {
+	return &UnisatBrc20Provider{
+		endpoint: strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		bearer:   strings.TrimSpace(bearer),
+	}
+}
+
+func (p *UnisatBrc20Provider) GetBrc20Balance(ctx context.Context, addr string, ticker string) (*big.Int, error) {
+	resp, err := unisat.GetAddressBrc20TickInfo(ctx, p.endpoint, p.bearer, addr, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("unisat GetAddressBrc20TickInfo error: %v", resp.Message)
+	}
+	balance, ok := big.NewInt(0).SetString(resp.Data.OverallBalance, 10)
+	if ok {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (p *UnisatBrc20Provider) GetBrc20Info(ctx context.Context, ticker string) (*Brc20Info, error) {
+	resp, err := unisat.GetBrc20Info(ctx, p.endpoint, p.bearer, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("unisat GetBrc20Info error: %v", resp.Message)
+	}
+	return &Brc20Info{
+		Ticker:      resp.Data.Ticker,
+		Decimals:    int32(resp.Data.Decimal),
+		Max:         resp.Data.Max,
+		TotalMinted: resp.Data.TotalMinted,
+	}, nil
+}
@@ -0,0 +1,348 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owlto-dao/utils-go/httputils"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+type CosmosCoin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type cosmosLatestBlock struct {
+	Block struct {
+		Header struct {
+			Height string `json:"height"`
+		} `json:"header"`
+	} `json:"block"`
+}
+
+type cosmosBalance struct {
+	Balance *CosmosCoin `json:"balance"`
+}
+
+type CosmosDenomMetadata struct {
+	Description string `json:"description"`
+	DenomUnits  []struct {
+		Denom    string `json:"denom"`
+		Exponent int32  `json:"exponent"`
+	} `json:"denom_units"`
+	Base    string `json:"base"`
+	Display string `json:"display"`
+	Name    string `json:"name"`
+	Symbol  string `json:"symbol"`
+}
+
+type cosmosDenomsMetadata struct {
+	Metadata CosmosDenomMetadata `json:"metadata"`
+}
+
+type cosmosDenomTrace struct {
+	DenomTrace struct {
+		Path      string `json:"path"`
+		BaseDenom string `json:"base_denom"`
+	} `json:"denom_trace"`
+}
+
+type CosmosTxResponse struct {
+	Height    string `json:"height"`
+	TxHash    string `json:"txhash"`
+	Codespace string `json:"codespace"`
+	Code      uint32 `json:"code"`
+	RawLog    string `json:"raw_log"`
+	GasWanted string `json:"gas_wanted"`
+	GasUsed   string `json:"gas_used"`
+	Timestamp string `json:"timestamp"`
+}
+
+// cosmosGrpcError is the body of the grpc gateway errors, code is a grpc status code
+type cosmosGrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const cosmosGrpcNotFound = 5
+
+type cosmosGetTx struct {
+	TxResponse *CosmosTxResponse `json:"tx_response"`
+}
+
+// CosmosDenomConfig describes a denom the chain has no bank metadata for
+type CosmosDenomConfig struct {
+	Symbol   string `mapstructure:"symbol"`
+	Decimals int32  `mapstructure:"decimals"`
+}
+
+// CosmosConfig describes how to reach the LCD of a cosmos chain. Denom is the gas token
+// denom the zero token address stands for, RpcEndPoint falls back to ChainInfo.RpcEndPoint.
+// Denoms maps base denoms to their token info, ibc tokens without metadata are looked up
+// there by the base denom of their trace.
+type CosmosConfig struct {
+	RpcEndPoint string                       `mapstructure:"rpc_end_point"`
+	Denom       string                       `mapstructure:"denom"`
+	Denoms      map[string]CosmosDenomConfig `mapstructure:"denoms"`
+}
+
+var cosmosConfigs = loader.NewChainConfigs[CosmosConfig]()
+
+func SetCosmosConfig(chainName string, cfg CosmosConfig) {
+	cosmosConfigs.Set(chainName, cfg)
+}
+
+func GetCosmosConfig(chainName string) CosmosConfig {
+	return cosmosConfigs.Get(chainName)
+}
+
+// CosmosRpc reads a cosmos sdk chain through its LCD. Tokens are bank denoms, native or
+// ibc/<hash>, the gas token is also reachable by a zero address.
+type CosmosRpc struct {
+	tokenInfoMgr *loader.TokenInfoManager
+	chainInfo    *loader.ChainInfo
+	endpoint     string
+	denom        string
+	denoms       map[string]CosmosDenomConfig
+	client       *httputils.Client
+}
+
+// NewCosmosRpc uses the config registered by SetCosmosConfig for the chain
+func NewCosmosRpc(chainInfo *loader.ChainInfo) *CosmosRpc {
+	return NewCosmosRpcFromConfig(chainInfo, GetCosmosConfig(chainInfo.Name))
+}
+
+func NewCosmosRpcFromConfig(chainInfo *loader.ChainInfo, cfg CosmosConfig) *CosmosRpc {
+	endpoint := strings.TrimSpace(cfg.RpcEndPoint)
+	if endpoint == "" {
+		endpoint = chainInfo.RpcEndPoint
+	}
+	return &CosmosRpc{
+		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
+		chainInfo:    chainInfo,
+		endpoint:     strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		denom:        strings.TrimSpace(cfg.Denom),
+		denoms:       cfg.Denoms,
+		client:       httpClientOf(chainInfo),
+	}
+}
+
+func (w *CosmosRpc) get(ctx context.Context, path string, result interface{}) error {
+	err := w.client.DoGet(ctx, w.endpoint+path, nil, result)
+	if err != nil {
+		return fmt.Errorf("cosmos get %s error: %w", path, err)
+	}
+	return nil
+}
+
+func (w *CosmosRpc) Client() interface{} {
+	return w.client
+}
+
+func (w *CosmosRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
+func (w *CosmosRpc) Backend() int32 {
+	return 7
+}
+
+// Denom resolves the zero token address to the gas token denom
+func (w *CosmosRpc) Denom(tokenAddr string) (string, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if !util.IsHexStringZero(tokenAddr) {
+		return tokenAddr, nil
+	}
+	if w.denom == "" {
+		return "", fmt.Errorf("%v gas token denom not configured", w.chainInfo.Name)
+	}
+	return w.denom, nil
+}
+
+func (w *CosmosRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	var block cosmosLatestBlock
+	err := w.get(ctx, "/cosmos/base/tendermint/v1beta1/blocks/latest", &block)
+	var height int64
+	if err == nil {
+		height, err = strconv.ParseInt(block.Block.Header.Height, 10, 64)
+	}
+	if err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return height, nil
+}
+
+func (w *CosmosRpc) GetDenomMetadata(ctx context.Context, denom string) (*CosmosDenomMetadata, error) {
+	var rsp cosmosDenomsMetadata
+	if err := w.get(ctx, "/cosmos/bank/v1beta1/denoms_metadata/"+strings.TrimSpace(denom), &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp.Metadata, nil
+}
+
+func (w *CosmosRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	tokenInfo, ok := w.tokenInfoMgr.GetByChainNameTokenAddr(w.chainInfo.Name, tokenAddr)
+	if ok {
+		return *tokenInfo, nil
+	}
+
+	var ti loader.TokenInfo
+	metadata, err := w.GetDenomMetadata(ctx, tokenAddr)
+	if err == nil {
+		ti, err = cosmosTokenInfo(metadata)
+	}
+	if err != nil && strings.HasPrefix(tokenAddr, "ibc/") {
+		// most ibc denoms have no metadata on the destination chain, fall back to the trace
+		ti, err = w.ibcTokenInfo(ctx, tokenAddr)
+	}
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+
+	ti.ChainName = w.chainInfo.Name
+	ti.TokenAddress = tokenAddr
+	ti.TotalSupply = big.NewInt(0)
+	w.tokenInfoMgr.AddTokenInfo(ti)
+	return ti, nil
+}
+
+// cosmosTokenInfo takes the decimals from the exponent of the display unit
+func cosmosTokenInfo(metadata *CosmosDenomMetadata) (loader.TokenInfo, error) {
+	symbol := metadata.Symbol
+	if symbol == "" {
+		symbol = strings.ToUpper(metadata.Display)
+	}
+	if symbol == "" {
+		return loader.TokenInfo{}, fmt.Errorf("denom %s has no symbol", metadata.Base)
+	}
+	for _, unit := range metadata.DenomUnits {
+		if unit.Denom == metadata.Display {
+			name := metadata.Name
+			if name == "" {
+				name = symbol
+			}
+			return loader.TokenInfo{TokenName: symbol, Decimals: unit.Exponent, FullName: name}, nil
+		}
+	}
+	return loader.TokenInfo{}, fmt.Errorf("denom %s has no display unit %s", metadata.Base, metadata.Display)
+}
+
+// ibcTokenInfo takes the token info of the base denom of the trace from the config, the chain
+// does not tell the decimals of a denom without metadata
+func (w *CosmosRpc) ibcTokenInfo(ctx context.Context, denom string) (loader.TokenInfo, error) {
+	var rsp cosmosDenomTrace
+	if err := w.get(ctx, "/ibc/apps/transfer/v1/denom_traces/"+strings.TrimPrefix(denom, "ibc/"), &rsp); err != nil {
+		return loader.TokenInfo{}, err
+	}
+	base := rsp.DenomTrace.BaseDenom
+	cfg, ok := w.denoms[base]
+	if !ok || cfg.Symbol == "" {
+		return loader.TokenInfo{}, fmt.Errorf("%s base denom %s has no metadata nor denom config", denom, base)
+	}
+	return loader.TokenInfo{TokenName: cfg.Symbol, Decimals: cfg.Decimals, FullName: rsp.DenomTrace.Path + "/" + base}, nil
+}
+
+func (w *CosmosRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	return w.GetBalance(ctx, ownerAddr, tokenAddr)
+}
+
+func (w *CosmosRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	denom, err := w.Denom(tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	var rsp cosmosBalance
+	if err := w.get(ctx, "/cosmos/bank/v1beta1/balances/"+ownerAddr+"/by_denom?denom="+url.QueryEscape(denom), &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Balance == nil {
+		return big.NewInt(0), nil
+	}
+	balance, ok := new(big.Int).SetString(rsp.Balance.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s of %s", rsp.Balance.Amount, ownerAddr)
+	}
+	return balance, nil
+}
+
+func (w *CosmosRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	return big.NewInt(0), fmt.Errorf("not impl")
+}
+
+// GetTx is nil while the tx is not in a block. Only the grpc NotFound of the LCD means
+// that, a plain 404 comes from a wrong end point and is an error.
+func (w *CosmosRpc) GetTx(ctx context.Context, hash string) (*CosmosTxResponse, error) {
+	hash = strings.TrimPrefix(strings.TrimSpace(hash), "0x")
+	var rsp cosmosGetTx
+	if err := w.get(ctx, "/cosmos/tx/v1beta1/txs/"+strings.ToUpper(hash), &rsp); err != nil {
+		if isCosmosNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rsp.TxResponse, nil
+}
+
+func isCosmosNotFound(err error) bool {
+	var statusErr *httputils.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	var rsp cosmosGrpcError
+	return json.Unmarshal(statusErr.Body, &rsp) == nil && rsp.Code == cosmosGrpcNotFound
+}
+
+func (w *CosmosRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus reports txs in the mempool as not found, the LCD only knows committed ones
+func (w *CosmosRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	hash = strings.TrimSpace(hash)
+	tx, err := w.GetTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+	}
+
+	height, err := strconv.ParseInt(tx.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx height %s: %w", tx.Height, err)
+	}
+	status := &TxStatus{Hash: hash, State: TxStateSuccess, BlockNumber: height}
+	status.GasUsed, _ = strconv.ParseUint(tx.GasUsed, 10, 64)
+	if blockTime, err := time.Parse(time.RFC3339, tx.Timestamp); err == nil {
+		status.BlockTime = blockTime.Unix()
+	}
+	if tx.Code != 0 {
+		status.State = TxStateReverted
+		status.RevertReason = fmt.Sprintf("%s code %d: %s", tx.Codespace, tx.Code, tx.RawLog)
+	}
+	return status, nil
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIbcHash        = "27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+	testUnknownIbcHash = "D189335C6E4A68B513C10AB227BF1C1D38C746766278BA3EEB4FB14124F1D858"
+)
+
+func TestCosmosRpc(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cosmos/base/tendermint/v1beta1/blocks/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"block":{"header":{"chain_id":"osmosis-1","height":"25000000"}}}`))
+	})
+	mux.HandleFunc("/cosmos/bank/v1beta1/balances/osmo1owner/by_denom", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("denom") {
+		case "uosmo":
+			w.Write([]byte(`{"balance":{"denom":"uosmo","amount":"1200000"}}`))
+		case "ibc/" + testIbcHash:
+			w.Write([]byte(`{"balance":{"denom":"ibc/` + testIbcHash + `","amount":"0"}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("/cosmos/bank/v1beta1/denoms_metadata/uosmo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"metadata":{"denom_units":[{"denom":"uosmo","exponent":0},{"denom":"osmo","exponent":6}],"base":"uosmo","display":"osmo","name":"Osmosis","symbol":"OSMO"}}`))
+	})
+	mux.HandleFunc("/ibc/apps/transfer/v1/denom_traces/"+testIbcHash, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"denom_trace":{"path":"transfer/channel-0","base_denom":"uatom"}}`))
+	})
+	mux.HandleFunc("/ibc/apps/transfer/v1/denom_traces/"+testUnknownIbcHash, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"denom_trace":{"path":"transfer/channel-750","base_denom":"uusdc"}}`))
+	})
+	mux.HandleFunc("/cosmos/tx/v1beta1/txs/AA", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tx_response":{"height":"24999990","txhash":"AA","code":0,"gas_used":"80000","timestamp":"2024-05-01T00:00:00Z"}}`))
+	})
+	mux.HandleFunc("/cosmos/tx/v1beta1/txs/BB", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tx_response":{"height":"24999991","txhash":"BB","codespace":"sdk","code":5,"raw_log":"insufficient funds","gas_used":"60000","timestamp":"2024-05-01T00:00:06Z"}}`))
+	})
+	mux.HandleFunc("/cosmos/tx/v1beta1/txs/CC", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":5,"message":"tx not found: CC","details":[]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chainInfo := &loader.ChainInfo{Name: "OsmosisMainnet", Backend: loader.CosmosBackend, RpcEndPoint: server.URL, GasTokenName: "OSMO", GasTokenDecimal: 6}
+	SetCosmosConfig("OsmosisMainnet", CosmosConfig{Denom: "uosmo", Denoms: map[string]CosmosDenomConfig{"uatom": {Symbol: "ATOM", Decimals: 6}}})
+	t.Cleanup(func() { cosmosConfigs.Delete("OsmosisMainnet") })
+	w, err := GetRpc(chainInfo)
+	require.NoError(t, err)
+	cosmosRpc := w.(*CosmosRpc)
+
+	height, err := cosmosRpc.GetLatestBlockNumber(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25000000), height)
+
+	balance, err := cosmosRpc.GetBalance(context.TODO(), "osmo1owner", "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1200000), balance)
+
+	balance, err = cosmosRpc.GetBalance(context.TODO(), "osmo1owner", "ibc/"+testIbcHash)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), balance)
+
+	info, err := cosmosRpc.GetTokenInfo(context.TODO(), "uosmo")
+	assert.NoError(t, err)
+	assert.Equal(t, "OSMO", info.TokenName)
+	assert.Equal(t, int32(6), info.Decimals)
+
+	info, err = cosmosRpc.GetTokenInfo(context.TODO(), "ibc/"+testIbcHash)
+	assert.NoError(t, err)
+	assert.Equal(t, "ATOM", info.TokenName)
+	assert.Equal(t, int32(6), info.Decimals)
+	assert.Equal(t, "transfer/channel-0/uatom", info.FullName)
+
+	// the decimals of an ibc denom without metadata nor config are not guessed
+	_, err = cosmosRpc.GetTokenInfo(context.TODO(), "ibc/"+testUnknownIbcHash)
+	assert.ErrorContains(t, err, "base denom uusdc")
+
+	_, err = cosmosRpc.GetTokenInfo(context.TODO(), "unknown")
+	assert.Error(t, err)
+
+	ok, block, err := cosmosRpc.IsTxSuccess(context.TODO(), "aa")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(24999990), block)
+
+	status, err := cosmosRpc.GetTxStatus(context.TODO(), "0xBB")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateReverted, status.State)
+	assert.Equal(t, uint64(60000), status.GasUsed)
+	assert.Equal(t, "sdk code 5: insufficient funds", status.RevertReason)
+
+	_, _, err = cosmosRpc.IsTxSuccess(context.TODO(), "CC")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+
+	// a 404 without the grpc NotFound body is a wrong end point, not a missing tx
+	_, err = cosmosRpc.GetTxStatus(context.TODO(), "DD")
+	assert.ErrorContains(t, err, "404")
+}
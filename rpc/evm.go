@@ -2,10 +2,12 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -22,11 +24,15 @@ import (
 type EvmRpc struct {
 	tokenInfoMgr *loader.TokenInfoManager
 	chainInfo    *loader.ChainInfo
-	erc20ABI     abi.ABI
+	erc20ABI     *abi.ABI
 }
 
 func NewEvmRpc(chainInfo *loader.ChainInfo) *EvmRpc {
-	erc20ABI, _ := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	// the metadata caches the parsed abi, so it is only parsed once per process
+	erc20ABI, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		log.Errorf("%v parse erc20 abi error %v", chainInfo.Name, err)
+	}
 	return &EvmRpc{
 		chainInfo:    chainInfo,
 		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
@@ -42,6 +48,10 @@ func (w *EvmRpc) Client() interface{} {
 	return w.chainInfo.Client
 }
 
+func (w *EvmRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
 func (w *EvmRpc) Backend() int32 {
 	return 1
 }
@@ -63,6 +73,10 @@ func (w *EvmRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.Tok
 		return *tokenInfo, nil
 	}
 
+	if w.erc20ABI == nil {
+		return loader.TokenInfo{}, fmt.Errorf("%v erc20 abi not available", w.chainInfo.Name)
+	}
+
 	var symbolHex hexutil.Bytes
 	var nameHex hexutil.Bytes
 	var decimalsHex hexutil.Bytes
@@ -231,15 +245,118 @@ func (w *EvmRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr str
 	}
 }
 
+// IsTxSuccess reads the receipt only, an unknown or pending tx returns ethereum.NotFound
 func (w *EvmRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	receipt, err := w.GetClient().TransactionReceipt(ctx, common.HexToHash(hash))
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// EvmTxStatusOptions asks GetTxStatusWithOptions for the fields costing extra requests
+type EvmTxStatusOptions struct {
+	// BlockTime reads the header of the tx block
+	BlockTime bool
+	// Finalized reads the header of the finalized tag
+	Finalized bool
+	// RevertReason replays a reverted tx
+	RevertReason bool
+}
+
+// GetTxStatus reads the receipt, and the tx when there is no receipt yet. BlockTime,
+// Finalized and RevertReason are left empty, see GetTxStatusWithOptions.
+func (w *EvmRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	return w.GetTxStatusWithOptions(ctx, hash, EvmTxStatusOptions{})
+}
+
+func (w *EvmRpc) GetTxStatusWithOptions(ctx context.Context, hash string, opts EvmTxStatusOptions) (*TxStatus, error) {
+	txHash := common.HexToHash(hash)
+	status := &TxStatus{Hash: txHash.Hex()}
+	receipt, err := w.GetClient().TransactionReceipt(ctx, txHash)
 	if err != nil {
-		return false, 0, err
+		if !isNotFoundError(err) {
+			return nil, err
+		}
+		// a known tx without receipt is either in the mempool or its receipt is not indexed yet
+		status.State = TxStatePending
+		if _, _, err := w.GetClient().TransactionByHash(ctx, txHash); err != nil {
+			if !isNotFoundError(err) {
+				return nil, err
+			}
+			status.State = TxStateNotFound
+		}
+		return status, nil
 	}
-	if receipt == nil {
-		return false, 0, fmt.Errorf("get receipt failed")
+
+	status.State = TxStateSuccess
+	status.BlockNumber = receipt.BlockNumber.Int64()
+	status.GasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		status.Fee = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	if opts.BlockTime {
+		if header, err := w.GetClient().HeaderByNumber(ctx, receipt.BlockNumber); err == nil {
+			status.BlockTime = int64(header.Time)
+		}
+	}
+	// nodes without the finalized tag leave finalized unset
+	if opts.Finalized {
+		if finalizedBlock, err := w.GetFinalizedBlockNumber(ctx); err == nil {
+			finalized := finalizedBlock >= status.BlockNumber
+			status.Finalized = &finalized
+		}
+	}
+	if receipt.Status != ethtypes.ReceiptStatusSuccessful {
+		status.State = TxStateReverted
+		if opts.RevertReason {
+			status.RevertReason = w.getRevertReason(ctx, txHash, receipt.BlockNumber)
+		}
+	}
+	return status, nil
+}
+
+// getRevertReason replays the tx on top of the parent block, which misses the txs before it
+// in the same block, so the reason is best effort. It is empty when the replay succeeds or
+// the node does not answer with revert data.
+func (w *EvmRpc) getRevertReason(ctx context.Context, txHash common.Hash, blockNumber *big.Int) string {
+	tx, _, err := w.GetClient().TransactionByHash(ctx, txHash)
+	if err != nil {
+		return ""
+	}
+	signer := ethtypes.LatestSignerForChainID(tx.ChainId())
+	if !tx.Protected() {
+		// pre EIP-155 legacy txs have no chain id in their signature
+		signer = ethtypes.HomesteadSigner{}
+	}
+	from, err := ethtypes.Sender(signer, tx)
+	if err != nil {
+		return ""
+	}
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Gas: tx.Gas(), Value: tx.Value(), Data: tx.Data()}
+	_, err = w.GetClient().CallContract(ctx, msg, new(big.Int).Sub(blockNumber, common.Big1))
+	if err == nil {
+		return ""
+	}
+
+	// transport errors, timeouts and rate limits are not revert reasons
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return ""
+	}
+	if data, ok := dataErr.ErrorData().(string); ok {
+		if revert, decodeErr := hexutil.Decode(data); decodeErr == nil {
+			if reason, unpackErr := abi.UnpackRevert(revert); unpackErr == nil {
+				return reason
+			}
+		}
+	}
+	return err.Error()
+}
+
+// GetFinalizedBlockNumber is the block of the finalized tag, nodes without it return an error
+func (w *EvmRpc) GetFinalizedBlockNumber(ctx context.Context) (int64, error) {
+	header, err := w.GetClient().HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	if err != nil {
+		return 0, err
 	}
-	return receipt.Status == ethtypes.ReceiptStatusSuccessful, receipt.BlockNumber.Int64(), nil
+	return header.Number.Int64(), nil
 }
 
 func (w *EvmRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
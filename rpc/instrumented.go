@@ -0,0 +1,319 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/hashicorp/go-metrics"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/telemetry"
+	"golang.org/x/time/rate"
+)
+
+const (
+	MetricLabelNameChain  = "chain"
+	MetricLabelNameMethod = "method"
+)
+
+var (
+	MetricKeyRpcLatency  = []string{"rpc", "latency"}
+	MetricKeyRpcRequests = []string{"rpc", "requests"}
+	MetricKeyRpcErrors   = []string{"rpc", "errors"}
+	MetricKeyRpcRetries  = []string{"rpc", "retries"}
+)
+
+type InstrumentedConfig struct {
+	// RateLimit is the number of calls per second allowed per chain, 0 disables rate limiting
+	RateLimit float64 `mapstructure:"rate_limit"`
+	// Burst is the token bucket size, defaults to 1 when RateLimit is set
+	Burst int `mapstructure:"burst"`
+	// MaxRetries is the number of retries after the first attempt for transient errors
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryBackoff is the initial backoff, doubled after each retry up to MaxBackoff
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	// CallTimeout bounds every single attempt, 0 means no timeout besides the caller's context
+	CallTimeout time.Duration `mapstructure:"call_timeout"`
+}
+
+func DefaultInstrumentedConfig() InstrumentedConfig {
+	return InstrumentedConfig{
+		MaxRetries:   2,
+		RetryBackoff: 200 * time.Millisecond,
+		MaxBackoff:   3 * time.Second,
+		CallTimeout:  10 * time.Second,
+	}
+}
+
+var (
+	chainLimiters      = make(map[string]*rate.Limiter)
+	chainLimitersMutex = &sync.Mutex{}
+)
+
+// getChainLimiter returns the token bucket shared by all instrumented rpcs of a chain.
+func getChainLimiter(chainName string, limit float64, burst int) *rate.Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	chainLimitersMutex.Lock()
+	defer chainLimitersMutex.Unlock()
+
+	key := strings.ToLower(chainName)
+	limiter, ok := chainLimiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		chainLimiters[key] = limiter
+		return limiter
+	}
+	if limiter.Limit() != rate.Limit(limit) {
+		limiter.SetLimit(rate.Limit(limit))
+	}
+	if limiter.Burst() != burst {
+		limiter.SetBurst(burst)
+	}
+	return limiter
+}
+
+// InstrumentedRpc decorates an Rpc with rate limiting, retries, per call timeouts and metrics.
+type InstrumentedRpc struct {
+	inner     Rpc
+	chainName string
+	cfg       InstrumentedConfig
+	limiter   *rate.Limiter
+}
+
+func NewInstrumentedRpc(inner Rpc, chainName string, cfg InstrumentedConfig) *InstrumentedRpc {
+	return &InstrumentedRpc{
+		inner:     inner,
+		chainName: chainName,
+		cfg:       cfg,
+		limiter:   getChainLimiter(chainName, cfg.RateLimit, cfg.Burst),
+	}
+}
+
+func (w *InstrumentedRpc) Unwrap() Rpc {
+	return w.inner
+}
+
+// retryableStatusCodes are the http statuses of an overloaded or briefly unavailable node
+var retryableStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooEarly:            true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// retryableRpcCodes are the json-rpc error codes of a limited or lagging node: EIP-1474 limit
+// exceeded, which solana also uses for an unhealthy node, and the solana missing block codes.
+var retryableRpcCodes = map[int]bool{
+	-32005: true,
+	-32004: true,
+	-32007: true,
+}
+
+// httpStatusPattern finds the status in the errors of network.RequestWithContext and httputils.Client,
+// which only report it as text
+var httpStatusPattern = regexp.MustCompile(`(?:unexpected status code (?:\S+ )?: |failed with status: |状态码: )(\d{3})\b`)
+
+// errorStatusCode is the http status or json-rpc error code carried by err, 0 when there is none
+func errorStatusCode(err error) (httpStatus int, rpcCode int) {
+	var gethHttpErr ethrpc.HTTPError
+	var solHttpErr *jsonrpc.HTTPError
+	var gethRpcErr ethrpc.Error
+	var solRpcErr *jsonrpc.RPCError
+	switch {
+	case errors.As(err, &gethHttpErr):
+		return gethHttpErr.StatusCode, 0
+	case errors.As(err, &solHttpErr):
+		return solHttpErr.Code, 0
+	case errors.As(err, &gethRpcErr):
+		return 0, gethRpcErr.ErrorCode()
+	case errors.As(err, &solRpcErr):
+		return 0, solRpcErr.Code
+	}
+	if match := httpStatusPattern.FindStringSubmatch(err.Error()); match != nil {
+		httpStatus, _ = strconv.Atoi(match[1])
+	}
+	return httpStatus, 0
+}
+
+// IsRetryableError reports whether err is a transient node or network failure: a network error,
+// a retryable http status or json-rpc code, or a known transient message. Reverts, missing data
+// and pending txs are final answers and are not retried.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ethereum.NotFound) || errors.Is(err, solrpc.ErrNotFound) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	httpStatus, rpcCode := errorStatusCode(err)
+	if httpStatus != 0 {
+		return retryableStatusCodes[httpStatus]
+	}
+	if rpcCode != 0 && retryableRpcCodes[rpcCode] {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, final := range []string{"revert", "not found", "not complete", "not impl", "unsupport", "invalid", "insufficient"} {
+		if strings.Contains(msg, final) {
+			return false
+		}
+	}
+	// errors wrapped with %v lose their type, a trailing eof is the closed connection
+	if strings.HasSuffix(msg, ": eof") || strings.HasSuffix(msg, "unexpected eof") {
+		return true
+	}
+	for _, transient := range []string{"timeout", "timed out", "connection reset", "connection refused", "broken pipe",
+		"too many requests", "rate limit", "bad gateway", "service unavailable", "temporarily unavailable"} {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *InstrumentedRpc) backoff(attempt int) time.Duration {
+	backoff := w.cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	for i := 0; i < attempt; i++ {
+		backoff *= 2
+		if w.cfg.MaxBackoff > 0 && backoff >= w.cfg.MaxBackoff {
+			backoff = w.cfg.MaxBackoff
+			break
+		}
+	}
+	// add up to 20% jitter so that callers do not retry in lockstep
+	return backoff + time.Duration(rand.Int63n(int64(backoff)/5+1))
+}
+
+func instrument[T any](ctx context.Context, w *InstrumentedRpc, method string, fn func(ctx context.Context) (T, error)) (T, error) {
+	labels := []metrics.Label{
+		telemetry.NewLabel(MetricLabelNameChain, w.chainName),
+		telemetry.NewLabel(MetricLabelNameMethod, method),
+	}
+	start := time.Now()
+	defer telemetry.MeasureSinceWithLabels(MetricKeyRpcLatency, start, labels)
+	telemetry.IncrCounterWithLabels(MetricKeyRpcRequests, 1, labels)
+
+	var result T
+	var err error
+	for attempt := 0; ; attempt++ {
+		if w.limiter != nil {
+			if err = w.limiter.Wait(ctx); err != nil {
+				break
+			}
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if w.cfg.CallTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, w.cfg.CallTimeout)
+		}
+		result, err = fn(callCtx)
+		cancel()
+
+		if err == nil || attempt >= w.cfg.MaxRetries || ctx.Err() != nil || !IsRetryableError(err) {
+			break
+		}
+
+		telemetry.IncrCounterWithLabels(MetricKeyRpcRetries, 1, labels)
+		log.Warnf("%v rpc %v attempt %d error %v, retrying", w.chainName, method, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(w.backoff(attempt)):
+		}
+	}
+
+	if err != nil {
+		telemetry.IncrCounterWithLabels(MetricKeyRpcErrors, 1, labels)
+	}
+	return result, err
+}
+
+func (w *InstrumentedRpc) Client() interface{} {
+	return w.inner.Client()
+}
+
+func (w *InstrumentedRpc) Backend() int32 {
+	return w.inner.Backend()
+}
+
+func (w *InstrumentedRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	return instrument(ctx, w, "GetLatestBlockNumber", func(ctx context.Context) (int64, error) {
+		return w.inner.GetLatestBlockNumber(ctx)
+	})
+}
+
+type txSuccessResult struct {
+	success     bool
+	blockNumber int64
+}
+
+func (w *InstrumentedRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	rsp, err := instrument(ctx, w, "IsTxSuccess", func(ctx context.Context) (txSuccessResult, error) {
+		success, blockNumber, err := w.inner.IsTxSuccess(ctx, hash)
+		return txSuccessResult{success: success, blockNumber: blockNumber}, err
+	})
+	return rsp.success, rsp.blockNumber, err
+}
+
+func (w *InstrumentedRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	return instrument(ctx, w, "GetTxStatus", func(ctx context.Context) (*TxStatus, error) {
+		return w.inner.GetTxStatus(ctx, hash)
+	})
+}
+
+func (w *InstrumentedRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	return instrument(ctx, w, "GetAllowance", func(ctx context.Context) (*big.Int, error) {
+		return w.inner.GetAllowance(ctx, ownerAddr, tokenAddr, spenderAddr)
+	})
+}
+
+func (w *InstrumentedRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	return instrument(ctx, w, "GetBalance", func(ctx context.Context) (*big.Int, error) {
+		return w.inner.GetBalance(ctx, ownerAddr, tokenAddr)
+	})
+}
+
+func (w *InstrumentedRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	return instrument(ctx, w, "GetBalanceAtBlockNumber", func(ctx context.Context) (*big.Int, error) {
+		return w.inner.GetBalanceAtBlockNumber(ctx, ownerAddr, tokenAddr, blockNumber)
+	})
+}
+
+func (w *InstrumentedRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	return instrument(ctx, w, "GetTokenInfo", func(ctx context.Context) (loader.TokenInfo, error) {
+		return w.inner.GetTokenInfo(ctx, tokenAddr)
+	})
+}
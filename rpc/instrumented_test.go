@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyRpc struct {
+	Rpc
+	errs  []error
+	calls int
+}
+
+func (f *flakyRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return big.NewInt(42), nil
+}
+
+func TestInstrumentedRpcRetry(t *testing.T) {
+	cfg := InstrumentedConfig{MaxRetries: 2, RetryBackoff: time.Millisecond}
+
+	inner := &flakyRpc{errs: []error{fmt.Errorf("unexpected status code : 502"), errors.New("connection reset by peer")}}
+	balance, err := NewInstrumentedRpc(inner, "TestChain", cfg).GetBalance(context.TODO(), "a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), balance)
+	assert.Equal(t, 3, inner.calls)
+
+	inner = &flakyRpc{errs: []error{ethereum.NotFound}}
+	_, err = NewInstrumentedRpc(inner, "TestChain", cfg).GetBalance(context.TODO(), "a", "b")
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	assert.Equal(t, 1, inner.calls)
+
+	inner = &flakyRpc{errs: []error{errors.New("timeout"), errors.New("timeout"), errors.New("timeout")}}
+	_, err = NewInstrumentedRpc(inner, "TestChain", cfg).GetBalance(context.TODO(), "a", "b")
+	assert.Error(t, err)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.False(t, IsRetryableError(nil))
+	assert.False(t, IsRetryableError(errors.New("execution reverted: ERC20: transfer amount exceeds balance")))
+	assert.False(t, IsRetryableError(context.Canceled))
+	assert.True(t, IsRetryableError(context.DeadlineExceeded))
+	assert.True(t, IsRetryableError(errors.New("429 Too Many Requests")))
+
+	// status codes come from the error types or the status text of the repo http helpers
+	assert.True(t, IsRetryableError(ethrpc.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}))
+	assert.False(t, IsRetryableError(ethrpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"}))
+	assert.True(t, IsRetryableError(fmt.Errorf("get balance: %w", &jsonrpc.HTTPError{Code: 429})))
+	assert.True(t, IsRetryableError(fmt.Errorf("toncenter get masterchainInfo error: %w", errors.New("failed with status: 502 Bad Gateway"))))
+	assert.True(t, IsRetryableError(errors.New("unexpected status code https://node/jsrpc : 504")))
+	assert.False(t, IsRetryableError(errors.New("unexpected status code https://node/jsrpc : 404")))
+	assert.True(t, IsRetryableError(&jsonrpc.RPCError{Code: -32005, Message: "Node is unhealthy"}))
+	assert.True(t, IsRetryableError(fmt.Errorf("error sending request : %v", io.EOF)))
+
+	// digits and words inside hashes, addresses and amounts are not statuses
+	assert.False(t, IsRetryableError(errors.New("nonce too low: tx 0x5020429c9a0eof50350400 amount 500")))
+	assert.False(t, IsRetryableError(&jsonrpc.RPCError{Code: -32002, Message: "Transaction simulation failed: 0x1f4 429"}))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"time"
 
+	"github.com/owlto-dao/utils-go/httputils"
 	"github.com/owlto-dao/utils-go/loader"
 )
 
@@ -13,6 +15,7 @@ type Rpc interface {
 	Backend() int32
 	GetLatestBlockNumber(ctx context.Context) (int64, error)
 	IsTxSuccess(ctx context.Context, hash string) (bool, int64, error)
+	GetTxStatus(ctx context.Context, hash string) (*TxStatus, error)
 	GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error)
 	GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error)
 	GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error)
@@ -27,9 +30,55 @@ func GetRpc(chainInfo *loader.ChainInfo) (Rpc, error) {
 	} else if chainInfo.Backend == 3 {
 		return NewSolanaRpc(chainInfo), nil
 	} else if chainInfo.Backend == 4 {
-		return NewBitcoinRpc(chainInfo), nil
+		btcRpc, err := NewBitcoinRpc(chainInfo)
+		if err != nil {
+			return nil, err
+		}
+		return btcRpc, nil
 	} else if chainInfo.Backend == 5 {
 		return NewZksliteRpc(chainInfo), nil
+	} else if chainInfo.Backend == 6 {
+		return NewTonRpc(chainInfo), nil
+	} else if chainInfo.Backend == 7 {
+		return NewCosmosRpc(chainInfo), nil
+	} else if chainInfo.Backend == 8 {
+		return NewBfcRpc(chainInfo), nil
+	} else if chainInfo.Backend == 9 {
+		return NewTronRpc(chainInfo), nil
 	}
 	return nil, fmt.Errorf("unsupport backend %v", chainInfo.Backend)
 }
+
+// httpClientOf is the client LoadAllChains creates for the http backends, a chain info built
+// by hand gets a new one
+func httpClientOf(chainInfo *loader.ChainInfo) *httputils.Client {
+	if client, ok := chainInfo.Client.(*httputils.Client); ok && client != nil {
+		return client
+	}
+	return httputils.NewClient(15 * time.Second)
+}
+
+// GetRpcWithTokenInfoManager is GetRpc with a token metadata cache shared by the caller
+func GetRpcWithTokenInfoManager(chainInfo *loader.ChainInfo, tokenInfoMgr *loader.TokenInfoManager) (Rpc, error) {
+	rpc, err := GetRpc(chainInfo)
+	if err != nil {
+		return nil, err
+	}
+	switch w := rpc.(type) {
+	case *EvmRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *StarknetRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *SolanaRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *TonRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *CosmosRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *BfcRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	case *TronRpc:
+		w.tokenInfoMgr = tokenInfoMgr
+	}
+	return rpc, nil
+}
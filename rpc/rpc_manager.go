@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+type rpcEntry struct {
+	chainInfo *loader.ChainInfo
+	rpc       Rpc
+}
+
+// RpcManager lazily creates one Rpc per chain of a ChainInfoManager. Instances are
+// rebuilt when the chain config changes, the token metadata cache of a chain survives
+// the rebuild.
+type RpcManager struct {
+	chainInfoMgr  *loader.ChainInfoManager
+	entries       map[int64]*rpcEntry
+	tokenInfoMgrs map[int64]*loader.TokenInfoManager
+	mutex         *sync.Mutex
+}
+
+func NewRpcManager(chainInfoMgr *loader.ChainInfoManager) *RpcManager {
+	return &RpcManager{
+		chainInfoMgr:  chainInfoMgr,
+		entries:       make(map[int64]*rpcEntry),
+		tokenInfoMgrs: make(map[int64]*loader.TokenInfoManager),
+		mutex:         &sync.Mutex{},
+	}
+}
+
+func (mgr *RpcManager) GetRpcById(id int64) (Rpc, error) {
+	chainInfo, ok := mgr.chainInfoMgr.GetChainInfoById(id)
+	if !ok {
+		return nil, fmt.Errorf("chain not found: id %v", id)
+	}
+	return mgr.GetRpc(chainInfo)
+}
+
+func (mgr *RpcManager) GetRpcByName(name string) (Rpc, error) {
+	chainInfo, ok := mgr.chainInfoMgr.GetChainInfoByName(name)
+	if !ok {
+		return nil, fmt.Errorf("chain not found: %v", name)
+	}
+	return mgr.GetRpc(chainInfo)
+}
+
+func (mgr *RpcManager) GetRpcByChainId(chainId string) (Rpc, error) {
+	chainInfo, ok := mgr.chainInfoMgr.GetChainInfoByChainId(chainId)
+	if !ok {
+		return nil, fmt.Errorf("chain not found: chainid %v", chainId)
+	}
+	return mgr.GetRpc(chainInfo)
+}
+
+func (mgr *RpcManager) GetRpcByNetcode(netcode int32) (Rpc, error) {
+	chainInfo, ok := mgr.chainInfoMgr.GetChainInfoByNetcode(netcode)
+	if !ok {
+		return nil, fmt.Errorf("chain not found: netcode %v", netcode)
+	}
+	return mgr.GetRpc(chainInfo)
+}
+
+// GetRpc returns the cached Rpc of the chain, or builds it if there is none yet or
+// the config it was built from has changed.
+func (mgr *RpcManager) GetRpc(chainInfo *loader.ChainInfo) (Rpc, error) {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+
+	entry, ok := mgr.entries[chainInfo.Id]
+	if ok && sameChainConfig(entry.chainInfo, chainInfo) {
+		return entry.rpc, nil
+	}
+
+	tokenInfoMgr, ok := mgr.tokenInfoMgrs[chainInfo.Id]
+	if !ok {
+		tokenInfoMgr = loader.NewTokenInfoManager(nil, nil)
+		mgr.tokenInfoMgrs[chainInfo.Id] = tokenInfoMgr
+	}
+	rpc, err := GetRpcWithTokenInfoManager(chainInfo, tokenInfoMgr)
+	if err != nil {
+		return nil, err
+	}
+	mgr.entries[chainInfo.Id] = &rpcEntry{chainInfo: chainInfo, rpc: rpc}
+	return rpc, nil
+}
+
+// sameChainConfig compares everything but the client, which the ChainInfoManager
+// recreates on every reload.
+func sameChainConfig(a *loader.ChainInfo, b *loader.ChainInfo) bool {
+	if a == b {
+		return true
+	}
+	ac, bc := *a, *b
+	ac.Client, bc.Client = nil, nil
+	return ac == bc
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRpcManagerRebuild(t *testing.T) {
+	mgr := NewRpcManager(loader.NewChainInfoManager(nil, nil))
+	chainInfo := &loader.ChainInfo{Id: 1, Name: "Ethereum", Backend: loader.EthereumBackend, RpcEndPoint: "https://a"}
+
+	first, err := mgr.GetRpc(chainInfo)
+	assert.NoError(t, err)
+	again, _ := mgr.GetRpc(chainInfo)
+	assert.Same(t, first, again)
+
+	// a reload with the same config keeps the instance
+	reloaded := *chainInfo
+	reloaded.Client = struct{}{}
+	again, _ = mgr.GetRpc(&reloaded)
+	assert.Same(t, first, again)
+
+	changed := *chainInfo
+	changed.RpcEndPoint = "https://b"
+	rebuilt, err := mgr.GetRpc(&changed)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, rebuilt)
+	assert.Same(t, first.(*EvmRpc).tokenInfoMgr, rebuilt.(*EvmRpc).tokenInfoMgr)
+
+	_, err = mgr.GetRpcByName("Ethereum")
+	assert.Error(t, err)
+}
+
+func TestRpcManagerLookup(t *testing.T) {
+	chainInfoMgr := loader.NewChainInfoManager(nil, nil)
+	chainInfo := &loader.ChainInfo{Id: 1, ChainId: "1", Name: "Ethereum", NetworkCode: 1, Backend: loader.EthereumBackend, RpcEndPoint: "https://a"}
+	chainInfoMgr.AddChainInfo(chainInfo)
+	mgr := NewRpcManager(chainInfoMgr)
+
+	byId, err := mgr.GetRpcById(1)
+	require.NoError(t, err)
+	assert.Same(t, chainInfo, byId.(*EvmRpc).GetChainInfo())
+	byName, err := mgr.GetRpcByName(" ethereum ")
+	require.NoError(t, err)
+	assert.Same(t, byId, byName)
+	byChainId, err := mgr.GetRpcByChainId("1")
+	require.NoError(t, err)
+	assert.Same(t, byId, byChainId)
+	byNetcode, err := mgr.GetRpcByNetcode(1)
+	require.NoError(t, err)
+	assert.Same(t, byId, byNetcode)
+
+	// a reloaded chain with a new endpoint is rebuilt on the next lookup, keeping its token cache
+	changed := *chainInfo
+	changed.RpcEndPoint = "https://b"
+	chainInfoMgr.AddChainInfo(&changed)
+	rebuilt, err := mgr.GetRpcByName("Ethereum")
+	require.NoError(t, err)
+	assert.NotSame(t, byId, rebuilt)
+	assert.Same(t, &changed, rebuilt.(*EvmRpc).GetChainInfo())
+	assert.Same(t, byId.(*EvmRpc).tokenInfoMgr, rebuilt.(*EvmRpc).tokenInfoMgr)
+	again, err := mgr.GetRpcByNetcode(1)
+	require.NoError(t, err)
+	assert.Same(t, rebuilt, again)
+
+	_, err = mgr.GetRpcById(2)
+	assert.Error(t, err)
+	_, err = mgr.GetRpcByChainId("2")
+	assert.Error(t, err)
+	_, err = mgr.GetRpcByNetcode(2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package rpctest
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// The abi bindings ship without bytecode, so the test contracts are compiled from the
+// sources in contracts/ with solc 0.8.21, optimizer on with 200 runs and evm version paris:
+//
+//	solc --optimize --optimize-runs 200 --evm-version paris --abi --bin -o contracts contracts/Erc20.sol contracts/Owlto20.sol
+
+var (
+	//go:embed contracts/Erc20.abi
+	erc20ArtifactAbi string
+	//go:embed contracts/Erc20.bin
+	erc20ArtifactBin string
+	//go:embed contracts/Owlto20.abi
+	owlto20ArtifactAbi string
+	//go:embed contracts/Owlto20.bin
+	owlto20ArtifactBin string
+)
+
+func decodeArtifactBin(bin string) ([]byte, error) {
+	code, err := hex.DecodeString(strings.TrimSpace(bin))
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract artifact: %w", err)
+	}
+	return code, nil
+}
+
+// Erc20Bytecode returns the deploy code of contracts/Erc20.sol minting supply to the deployer
+func Erc20Bytecode(name string, symbol string, decimals uint8, supply *big.Int) ([]byte, error) {
+	code, err := decodeArtifactBin(erc20ArtifactBin)
+	if err != nil {
+		return nil, err
+	}
+	erc20Abi, err := abi.JSON(strings.NewReader(erc20ArtifactAbi))
+	if err != nil {
+		return nil, err
+	}
+	args, err := erc20Abi.Pack("", name, symbol, decimals, supply)
+	if err != nil {
+		return nil, err
+	}
+	return append(code, args...), nil
+}
+
+// Owlto20Bytecode returns the deploy code of contracts/Owlto20.sol, transfer forwards the
+// native value or pulls the erc20 from the sender to the maker and emits Deposit
+func Owlto20Bytecode() ([]byte, error) {
+	return decodeArtifactBin(owlto20ArtifactBin)
+}
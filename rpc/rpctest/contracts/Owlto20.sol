@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.21;
+
+interface IErc20 {
+    function transferFrom(address from, address to, uint256 value) external returns (bool);
+}
+
+// Owlto20 implements the abi of abi/owlto: transfer moves the native value or pulls the
+// erc20 from the sender to the maker and emits Deposit for the relayer
+contract Owlto20 {
+    error AmountError();
+    error LengthError();
+    error ZeroAddressError();
+
+    event Deposit(
+        address indexed user,
+        address indexed token,
+        address indexed maker,
+        string target,
+        uint256 amount,
+        uint256 timestamp
+    );
+
+    receive() external payable {}
+
+    function isOwltoTransfer() external pure returns (bool) {
+        return true;
+    }
+
+    function transfer(string calldata target, address ercToken, address maker, uint256 amount) external payable {
+        if (maker == address(0)) {
+            revert ZeroAddressError();
+        }
+        if (bytes(target).length == 0) {
+            revert LengthError();
+        }
+        if (ercToken == address(0)) {
+            if (msg.value != amount) {
+                revert AmountError();
+            }
+            (bool ok, ) = maker.call{value: amount}("");
+            require(ok, "native transfer failed");
+        } else {
+            if (msg.value != 0) {
+                revert AmountError();
+            }
+            _safeTransferFrom(ercToken, msg.sender, maker, amount);
+        }
+        emit Deposit(msg.sender, ercToken, maker, target, amount, block.timestamp);
+    }
+
+    // _safeTransferFrom accepts tokens returning nothing, like usdt
+    function _safeTransferFrom(address token, address from, address to, uint256 amount) internal {
+        (bool ok, bytes memory data) = token.call(abi.encodeCall(IErc20.transferFrom, (from, to, amount)));
+        require(ok && (data.length == 0 || abi.decode(data, (bool))), "transferFrom failed");
+    }
+}
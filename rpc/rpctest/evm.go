@@ -0,0 +1,239 @@
+package rpctest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/owlto-dao/utils-go/abi/erc20"
+	owlto20 "github.com/owlto-dao/utils-go/abi/owlto"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+// SimulatedChainId is the chain id of geth's dev chain config
+const SimulatedChainId = 1337
+
+// SimulatedEvm is an in-process geth dev node, go-ethereum's ethclient/simulated backend,
+// reachable through a real *ethclient.Client so EvmRpc and the txn/evm builders run against
+// it unchanged. Transactions are only mined on Commit. The node of go-ethereum v1.13 pulls in
+// fjl/memsize, so binaries using it link with go 1.22 at most.
+type SimulatedEvm struct {
+	Backend   *simulated.Backend
+	Client    *ethclient.Client
+	ChainInfo *loader.ChainInfo
+	Rpc       *rpc.EvmRpc
+	// Accounts are prefunded with 1000 ether each
+	Accounts []*ecdsa.PrivateKey
+
+	ipcDir string
+}
+
+func NewSimulatedEvm(accounts int) (*SimulatedEvm, error) {
+	if accounts <= 0 {
+		accounts = 1
+	}
+	balance := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	alloc := make(types.GenesisAlloc)
+	keys := make([]*ecdsa.PrivateKey, 0, accounts)
+	for i := 0; i < accounts; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: balance}
+	}
+
+	// the backend only hands out a client interface, the ipc endpoint gives the *ethclient.Client
+	// that ChainInfo.Client holds for evm chains
+	ipcDir, err := os.MkdirTemp("", "rpctest")
+	if err != nil {
+		return nil, err
+	}
+	ipcPath := filepath.Join(ipcDir, "geth.ipc")
+	backend := simulated.NewBackend(alloc, func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		nodeConf.IPCPath = ipcPath
+	})
+	client, err := ethclient.Dial(ipcPath)
+	if err != nil {
+		backend.Close()
+		os.RemoveAll(ipcDir)
+		return nil, err
+	}
+
+	chainInfo := &loader.ChainInfo{
+		ChainId:         fmt.Sprintf("%d", SimulatedChainId),
+		RealChainId:     fmt.Sprintf("%d", SimulatedChainId),
+		Name:            "Simulated",
+		AliasName:       "Simulated",
+		Backend:         loader.EthereumBackend,
+		Eip1559:         1,
+		GasTokenName:    "ETH",
+		GasTokenDecimal: 18,
+		Client:          client,
+	}
+	return &SimulatedEvm{
+		Backend:   backend,
+		Client:    client,
+		ChainInfo: chainInfo,
+		Rpc:       rpc.NewEvmRpc(chainInfo),
+		Accounts:  keys,
+		ipcDir:    ipcDir,
+	}, nil
+}
+
+func (s *SimulatedEvm) Close() {
+	s.Client.Close()
+	s.Backend.Close()
+	os.RemoveAll(s.ipcDir)
+}
+
+func (s *SimulatedEvm) Address(index int) common.Address {
+	return crypto.PubkeyToAddress(s.Accounts[index].PublicKey)
+}
+
+func (s *SimulatedEvm) Transactor(index int) (*bind.TransactOpts, error) {
+	return bind.NewKeyedTransactorWithChainID(s.Accounts[index], big.NewInt(SimulatedChainId))
+}
+
+// Commit mines the pending transactions into a new block
+func (s *SimulatedEvm) Commit() (common.Hash, error) {
+	return s.Backend.Commit(), nil
+}
+
+// Mine commits a block and returns the receipt of tx
+func (s *SimulatedEvm) Mine(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	if _, err := s.Commit(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return bind.WaitMined(ctx, s.Client, tx)
+}
+
+// SendTx signs, sends and mines a tx from the account at index, to nil creates a contract
+// and a zero gas is estimated.
+func (s *SimulatedEvm) SendTx(ctx context.Context, index int, to *common.Address, value *big.Int, data []byte, gas uint64) (*types.Receipt, error) {
+	from := s.Address(index)
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	nonce, err := s.Client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := s.Client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if gas == 0 {
+		gas, err = s.Client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: to, Value: value, Data: data})
+		if err != nil {
+			return nil, err
+		}
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       to,
+		Value:    value,
+		Gas:      gas,
+		GasPrice: gasPrice,
+		Data:     data,
+	}), types.LatestSignerForChainID(big.NewInt(SimulatedChainId)), s.Accounts[index])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Client.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	return s.Mine(ctx, tx)
+}
+
+// SendBody sends the json body returned by the txn/evm builders from the account at index
+func (s *SimulatedEvm) SendBody(ctx context.Context, index int, body []byte) (*types.Receipt, error) {
+	var m struct {
+		To    common.Address `json:"to"`
+		Gas   hexutil.Uint64 `json:"gas"`
+		Value *hexutil.Big   `json:"value"`
+		Input hexutil.Bytes  `json:"input"`
+	}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	return s.SendTx(ctx, index, &m.To, (*big.Int)(m.Value), m.Input, uint64(m.Gas))
+}
+
+// deploy creates a contract with the abi of its binding, the bindings ship without bytecode
+// so code comes from the solc artifacts in contracts.go
+func (s *SimulatedEvm) deploy(ctx context.Context, index int, contractAbi *abi.ABI, code []byte) (common.Address, error) {
+	opts, err := s.Transactor(index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	opts.Context = ctx
+	_, tx, _, err := bind.DeployContract(opts, *contractAbi, code, s.Client)
+	if err != nil {
+		return common.Address{}, err
+	}
+	receipt, err := s.Mine(ctx, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, fmt.Errorf("deploy reverted: %s", receipt.TxHash)
+	}
+	return receipt.ContractAddress, nil
+}
+
+// DeployErc20 deploys contracts/Erc20.sol minting supply to the account at index
+func (s *SimulatedEvm) DeployErc20(ctx context.Context, index int, name string, symbol string, decimals uint8, supply *big.Int) (common.Address, *erc20.Erc20, error) {
+	erc20Abi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	code, err := Erc20Bytecode(name, symbol, decimals, supply)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	addr, err := s.deploy(ctx, index, erc20Abi, code)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	token, err := erc20.NewErc20(addr, s.Client)
+	return addr, token, err
+}
+
+// DeployOwlto20 deploys contracts/Owlto20.sol
+func (s *SimulatedEvm) DeployOwlto20(ctx context.Context, index int) (common.Address, *owlto20.Owlto20, error) {
+	owltoAbi, err := owlto20.Owlto20MetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	code, err := Owlto20Bytecode()
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	addr, err := s.deploy(ctx, index, owltoAbi, code)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	contract, err := owlto20.NewOwlto20(addr, s.Client)
+	return addr, contract, err
+}
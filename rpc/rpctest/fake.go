@@ -0,0 +1,362 @@
+package rpctest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// FakeTx is the scripted outcome of a tx, Pending txs answer "not complete"
+type FakeTx struct {
+	Success      bool
+	BlockNumber  int64
+	Pending      bool
+	Dropped      bool
+	Fee          *big.Int
+	RevertReason string
+	Err          error
+}
+
+type balancePoint struct {
+	blockNumber int64
+	amount      *big.Int
+}
+
+// FakeRpc is a scriptable in-memory rpc.Rpc for unit tests. Balances are recorded
+// per block so GetBalanceAtBlockNumber sees the history, errors can be injected per
+// method either permanently or for the next calls only.
+type FakeRpc struct {
+	backend     int32
+	client      interface{}
+	blockNumber int64
+	finalized   *int64 // nil when the fake has no finalized block
+	balances    map[string][]balancePoint
+	allowances  map[string]*big.Int
+	txs         map[string]FakeTx
+	txSeqs      map[string][]FakeTx
+	tokens      map[string]loader.TokenInfo
+	errs        map[string]error
+	nextErrs    map[string][]error
+	latency     time.Duration
+	calls       map[string]int
+	mutex       *sync.Mutex
+}
+
+var _ rpc.Rpc = (*FakeRpc)(nil)
+
+func NewFakeRpc(backend loader.Backend) *FakeRpc {
+	return &FakeRpc{
+		backend:    int32(backend),
+		balances:   make(map[string][]balancePoint),
+		allowances: make(map[string]*big.Int),
+		txs:        make(map[string]FakeTx),
+		txSeqs:     make(map[string][]FakeTx),
+		tokens:     make(map[string]loader.TokenInfo),
+		errs:       make(map[string]error),
+		nextErrs:   make(map[string][]error),
+		calls:      make(map[string]int),
+		mutex:      &sync.Mutex{},
+	}
+}
+
+// normalize lowercases hex addresses only, base58 addresses are case sensitive
+func normalize(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if util.IsHexStringZero(addr) {
+		return "0x0"
+	}
+	if strings.HasPrefix(addr, "0x") || strings.HasPrefix(addr, "0X") {
+		return strings.ToLower(addr)
+	}
+	return addr
+}
+
+func key(addrs ...string) string {
+	for i, addr := range addrs {
+		addrs[i] = normalize(addr)
+	}
+	return strings.Join(addrs, "|")
+}
+
+func (f *FakeRpc) SetClient(client interface{}) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.client = client
+	return f
+}
+
+func (f *FakeRpc) SetBlockNumber(blockNumber int64) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.blockNumber = blockNumber
+	return f
+}
+
+// SetBalance sets the balance from the current block on
+func (f *FakeRpc) SetBalance(ownerAddr string, tokenAddr string, amount *big.Int) *FakeRpc {
+	f.mutex.Lock()
+	blockNumber := f.blockNumber
+	f.mutex.Unlock()
+	return f.SetBalanceAt(ownerAddr, tokenAddr, blockNumber, amount)
+}
+
+// SetBalanceAt sets the balance from blockNumber on, until a later block changes it
+func (f *FakeRpc) SetBalanceAt(ownerAddr string, tokenAddr string, blockNumber int64, amount *big.Int) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	k := key(ownerAddr, tokenAddr)
+	points := f.balances[k]
+	index := sort.Search(len(points), func(i int) bool { return points[i].blockNumber >= blockNumber })
+	point := balancePoint{blockNumber: blockNumber, amount: new(big.Int).Set(amount)}
+	if index < len(points) && points[index].blockNumber == blockNumber {
+		points[index] = point
+	} else {
+		points = append(points, balancePoint{})
+		copy(points[index+1:], points[index:])
+		points[index] = point
+	}
+	f.balances[k] = points
+	return f
+}
+
+func (f *FakeRpc) SetAllowance(ownerAddr string, tokenAddr string, spenderAddr string, amount *big.Int) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.allowances[key(ownerAddr, tokenAddr, spenderAddr)] = new(big.Int).Set(amount)
+	return f
+}
+
+// SetFinalizedBlockNumber makes the fake report a finalized block like an evm node
+func (f *FakeRpc) SetFinalizedBlockNumber(blockNumber int64) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.finalized = &blockNumber
+	return f
+}
+
+func (f *FakeRpc) SetTx(hash string, tx FakeTx) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.txSeqs, key(hash))
+	f.txs[key(hash)] = tx
+	return f
+}
+
+// SetTxSequence scripts the states of a tx, every status read moves to the next one and
+// the last one stays
+func (f *FakeRpc) SetTxSequence(hash string, txs ...FakeTx) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.txs, key(hash))
+	f.txSeqs[key(hash)] = append([]FakeTx(nil), txs...)
+	return f
+}
+
+func (f *FakeRpc) SetTokenInfo(tokenInfo loader.TokenInfo) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.tokens[key(tokenInfo.TokenAddress)] = tokenInfo
+	return f
+}
+
+// SetError makes every call of method fail with err, a nil err clears it
+func (f *FakeRpc) SetError(method string, err error) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+	} else {
+		f.errs[method] = err
+	}
+	return f
+}
+
+// FailNext makes the next calls of method fail with errs, one per call
+func (f *FakeRpc) FailNext(method string, errs ...error) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.nextErrs[method] = append(f.nextErrs[method], errs...)
+	return f
+}
+
+// SetLatency delays every call, a call returns early with the context error if it is done first
+func (f *FakeRpc) SetLatency(latency time.Duration) *FakeRpc {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.latency = latency
+	return f
+}
+
+func (f *FakeRpc) Calls(method string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.calls[method]
+}
+
+// enter records the call, waits the latency and returns the injected error if any
+func (f *FakeRpc) enter(ctx context.Context, method string) error {
+	f.mutex.Lock()
+	f.calls[method]++
+	latency := f.latency
+	f.mutex.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if errs := f.nextErrs[method]; len(errs) > 0 {
+		f.nextErrs[method] = errs[1:]
+		return errs[0]
+	}
+	return f.errs[method]
+}
+
+func (f *FakeRpc) Client() interface{} {
+	return f.client
+}
+
+func (f *FakeRpc) Backend() int32 {
+	return f.backend
+}
+
+func (f *FakeRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	if err := f.enter(ctx, "GetLatestBlockNumber"); err != nil {
+		return 0, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.blockNumber, nil
+}
+
+func (f *FakeRpc) GetFinalizedBlockNumber(ctx context.Context) (int64, error) {
+	if err := f.enter(ctx, "GetFinalizedBlockNumber"); err != nil {
+		return 0, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.finalized == nil {
+		return 0, fmt.Errorf("finalized block %w", ErrNotFound)
+	}
+	return *f.finalized, nil
+}
+
+func (f *FakeRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	if err := f.enter(ctx, "IsTxSuccess"); err != nil {
+		return false, 0, err
+	}
+	return rpc.IsTxSuccessFromStatus(f.txStatus(hash))
+}
+
+func (f *FakeRpc) GetTxStatus(ctx context.Context, hash string) (*rpc.TxStatus, error) {
+	if err := f.enter(ctx, "GetTxStatus"); err != nil {
+		return nil, err
+	}
+	return f.txStatus(hash)
+}
+
+func (f *FakeRpc) txStatus(hash string) (*rpc.TxStatus, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	status := &rpc.TxStatus{Hash: hash, State: rpc.TxStateNotFound}
+	tx, ok := f.txs[key(hash)]
+	if seq := f.txSeqs[key(hash)]; len(seq) > 0 {
+		tx, ok = seq[0], true
+		if len(seq) > 1 {
+			f.txSeqs[key(hash)] = seq[1:]
+		}
+	}
+	if !ok {
+		return status, nil
+	}
+	if tx.Err != nil {
+		return nil, tx.Err
+	}
+	switch {
+	case tx.Pending:
+		status.State = rpc.TxStatePending
+	case tx.Dropped:
+		status.State = rpc.TxStateDropped
+	case tx.Success:
+		status.State = rpc.TxStateSuccess
+	default:
+		status.State = rpc.TxStateReverted
+	}
+	if status.IsIncluded() {
+		status.BlockNumber = tx.BlockNumber
+		status.Fee = tx.Fee
+	}
+	status.RevertReason = tx.RevertReason
+	return status, nil
+}
+
+func (f *FakeRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	if err := f.enter(ctx, "GetAllowance"); err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if allowance, ok := f.allowances[key(ownerAddr, tokenAddr, spenderAddr)]; ok {
+		return new(big.Int).Set(allowance), nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (f *FakeRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	if err := f.enter(ctx, "GetBalance"); err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.balanceAt(ownerAddr, tokenAddr, f.blockNumber), nil
+}
+
+func (f *FakeRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	if err := f.enter(ctx, "GetBalanceAtBlockNumber"); err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if blockNumber > f.blockNumber {
+		return nil, fmt.Errorf("block %d %w", blockNumber, ErrNotFound)
+	}
+	return f.balanceAt(ownerAddr, tokenAddr, blockNumber), nil
+}
+
+func (f *FakeRpc) balanceAt(ownerAddr string, tokenAddr string, blockNumber int64) *big.Int {
+	points := f.balances[key(ownerAddr, tokenAddr)]
+	index := sort.Search(len(points), func(i int) bool { return points[i].blockNumber > blockNumber })
+	if index == 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(points[index-1].amount)
+}
+
+func (f *FakeRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	if err := f.enter(ctx, "GetTokenInfo"); err != nil {
+		return loader.TokenInfo{}, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	tokenInfo, ok := f.tokens[key(tokenAddr)]
+	if !ok {
+		return loader.TokenInfo{}, fmt.Errorf("token %s %w", tokenAddr, ErrNotFound)
+	}
+	return tokenInfo, nil
+}
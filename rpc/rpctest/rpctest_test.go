@@ -0,0 +1,297 @@
+package rpctest
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/owlto-dao/utils-go/abi/erc20"
+	owlto20 "github.com/owlto-dao/utils-go/abi/owlto"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/owlto-dao/utils-go/txn/evm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeRpc(t *testing.T) {
+	ctx := context.TODO()
+	owner := "0xAbC0000000000000000000000000000000000001"
+	token := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+	fake := NewFakeRpc(loader.EthereumBackend).
+		SetBlockNumber(10).
+		SetBalanceAt(owner, token, 5, big.NewInt(100)).
+		SetBalanceAt(owner, token, 8, big.NewInt(70)).
+		SetTx("0x01", FakeTx{Success: true, BlockNumber: 9}).
+		SetTx("0x02", FakeTx{Pending: true})
+
+	balance, err := fake.GetBalance(ctx, owner, token)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(70), balance)
+	balance, _ = fake.GetBalanceAtBlockNumber(ctx, owner, token, 6)
+	assert.Equal(t, big.NewInt(100), balance)
+	balance, _ = fake.GetBalanceAtBlockNumber(ctx, owner, token, 4)
+	assert.Equal(t, big.NewInt(0), balance)
+
+	success, blockNumber, err := fake.IsTxSuccess(ctx, "0x01")
+	assert.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, int64(9), blockNumber)
+	_, _, err = fake.IsTxSuccess(ctx, "0x02")
+	assert.ErrorContains(t, err, "not complete")
+	_, err = fake.GetTokenInfo(ctx, token)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	fake.FailNext("GetBalance", errors.New("502 bad gateway"))
+	_, err = fake.GetBalance(ctx, owner, token)
+	assert.Error(t, err)
+	_, err = fake.GetBalance(ctx, owner, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fake.Calls("GetBalance"))
+
+	fake.SetLatency(time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = fake.GetLatestBlockNumber(timeoutCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the fake composes with the rpc decorators
+	fake.SetLatency(0).FailNext("GetLatestBlockNumber", errors.New("connection reset by peer"))
+	cfg := rpc.InstrumentedConfig{MaxRetries: 1, RetryBackoff: time.Millisecond}
+	blockNumber, err = rpc.NewInstrumentedRpc(fake, "Fake", cfg).GetLatestBlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), blockNumber)
+}
+
+func TestContractArtifacts(t *testing.T) {
+	// the compiled contracts must serve every method and event of the bindings
+	for _, c := range []struct {
+		binding  func() (*abi.ABI, error)
+		artifact string
+	}{
+		{erc20.Erc20MetaData.GetAbi, erc20ArtifactAbi},
+		{owlto20.Owlto20MetaData.GetAbi, owlto20ArtifactAbi},
+	} {
+		binding, err := c.binding()
+		require.NoError(t, err)
+		artifact, err := abi.JSON(strings.NewReader(c.artifact))
+		require.NoError(t, err)
+		for name, method := range binding.Methods {
+			assert.Equal(t, method.Sig, artifact.Methods[name].Sig, name)
+		}
+		for name, event := range binding.Events {
+			assert.Equal(t, event.ID, artifact.Events[name].ID, name)
+		}
+	}
+}
+
+func TestSimulatedEvm(t *testing.T) {
+	ctx := context.TODO()
+	sim, err := NewSimulatedEvm(3)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	owner, maker := sim.Address(0), sim.Address(2)
+	supply := big.NewInt(1_000_000)
+	tokenAddr, token, err := sim.DeployErc20(ctx, 0, "Test Token", "TST", 6, supply)
+	require.NoError(t, err)
+	owltoAddr, owlto, err := sim.DeployOwlto20(ctx, 1)
+	require.NoError(t, err)
+
+	symbol, err := token.Symbol(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "TST", symbol)
+	isOwlto, err := owlto.IsOwltoTransfer(nil)
+	assert.NoError(t, err)
+	assert.True(t, isOwlto)
+
+	tokenInfo, err := sim.Rpc.GetTokenInfo(ctx, tokenAddr.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), tokenInfo.Decimals)
+	assert.Equal(t, supply, tokenInfo.TotalSupply)
+
+	body, err := evm.Erc20ApproveBody(sim.Client, owner.Hex(), tokenAddr.Hex(), owltoAddr.Hex(), big.NewInt(500))
+	require.NoError(t, err)
+	receipt, err := sim.SendBody(ctx, 0, body)
+	require.NoError(t, err)
+	success, _, err := sim.Rpc.IsTxSuccess(ctx, receipt.TxHash.Hex())
+	assert.NoError(t, err)
+	assert.True(t, success)
+
+	allowance, err := sim.Rpc.GetAllowance(ctx, owner.Hex(), tokenAddr.Hex(), owltoAddr.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(500), allowance)
+
+	opts, err := sim.Transactor(0)
+	require.NoError(t, err)
+	tx, err := owlto.Transfer(opts, "0xtarget", tokenAddr, maker, big.NewInt(300))
+	require.NoError(t, err)
+	receipt, err = sim.Mine(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	// blocks come with their bodies, as from a real node
+	block, err := sim.Client.BlockByNumber(ctx, receipt.BlockNumber)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions(), 1)
+	assert.Equal(t, tx.Hash(), block.Transactions()[0].Hash())
+
+	deposit, err := owlto.ParseDeposit(*receipt.Logs[len(receipt.Logs)-1])
+	assert.NoError(t, err)
+	assert.Equal(t, "0xtarget", deposit.Target)
+	assert.Equal(t, maker, deposit.Maker)
+
+	balance, err := sim.Rpc.GetBalance(ctx, maker.Hex(), tokenAddr.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(300), balance)
+	balance, err = sim.Rpc.GetBalanceAtBlockNumber(ctx, owner.Hex(), tokenAddr.Hex(), receipt.BlockNumber.Int64()-1)
+	assert.NoError(t, err)
+	assert.Equal(t, supply, balance)
+
+	// native transfers must carry exactly the amount
+	opts.Value = big.NewInt(1e18)
+	tx, err = owlto.Transfer(opts, "0xtarget", common.Address{}, maker, big.NewInt(1e18))
+	require.NoError(t, err)
+	receipt, err = sim.Mine(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	// more than the remaining allowance reverts
+	opts.Value = nil
+	opts.GasLimit = 200000
+	tx, err = owlto.Transfer(opts, "0xtarget", tokenAddr, maker, big.NewInt(201))
+	require.NoError(t, err)
+	receipt, err = sim.Mine(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+
+	status, err := sim.Rpc.GetTxStatus(ctx, tx.Hash().Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.TxStateReverted, status.State)
+	assert.Equal(t, receipt.BlockNumber.Int64(), status.BlockNumber)
+	assert.Equal(t, receipt.GasUsed, status.GasUsed)
+	assert.Zero(t, status.BlockTime)
+	assert.Nil(t, status.Finalized)
+	assert.Empty(t, status.RevertReason)
+	status, err = sim.Rpc.GetTxStatusWithOptions(ctx, tx.Hash().Hex(), rpc.EvmTxStatusOptions{BlockTime: true, Finalized: true, RevertReason: true})
+	assert.NoError(t, err)
+	assert.NotZero(t, status.BlockTime)
+	assert.NotNil(t, status.Finalized)
+	assert.NotEmpty(t, status.RevertReason)
+	status, err = sim.Rpc.GetTxStatus(ctx, common.Hash{1}.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.TxStateNotFound, status.State)
+
+	// IsTxSuccess keeps go-ethereum's NotFound for unknown and pending txs
+	_, _, err = sim.Rpc.IsTxSuccess(ctx, common.Hash{1}.Hex())
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	opts.GasLimit = 0
+	tx, err = owlto.Transfer(opts, "0xtarget", tokenAddr, maker, big.NewInt(1))
+	require.NoError(t, err)
+	_, _, err = sim.Rpc.IsTxSuccess(ctx, tx.Hash().Hex())
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	assert.ErrorContains(t, err, "not complete")
+	status, err = sim.Rpc.GetTxStatus(ctx, tx.Hash().Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.TxStatePending, status.State)
+}
+
+func TestWaitForTx(t *testing.T) {
+	ctx := context.TODO()
+	opts := rpc.WaitOptions{PollInterval: time.Millisecond, MaxPollInterval: 5 * time.Millisecond}
+
+	fake := NewFakeRpc(loader.EthereumBackend).SetBlockNumber(10).
+		SetTxSequence("0x01", FakeTx{Pending: true}, FakeTx{Pending: true}, FakeTx{Success: true, BlockNumber: 9})
+	result, err := rpc.WaitForTx(ctx, fake, "0x01", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.WaitStatusSuccess, result.Status)
+	assert.Equal(t, int64(9), result.BlockNumber)
+	assert.Equal(t, 3, fake.Calls("GetTxStatus"))
+
+	fake.SetTx("0x02", FakeTx{Success: false, BlockNumber: 9})
+	result, err = rpc.WaitForTx(ctx, fake, "0x02", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.WaitStatusFailed, result.Status)
+
+	fake.SetTx("0x04", FakeTx{Dropped: true, RevertReason: "nonce too low"})
+	result, err = rpc.WaitForTx(ctx, fake, "0x04", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.WaitStatusDropped, result.Status)
+	// a dropped tx failed, callers must not poll it again
+	success, blockNumber, err := fake.IsTxSuccess(ctx, "0x04")
+	assert.NoError(t, err)
+	assert.False(t, success)
+	assert.Zero(t, blockNumber)
+
+	dropOpts := opts
+	dropOpts.DroppedAfter = 10 * time.Millisecond
+	result, err = rpc.WaitForTx(ctx, fake, "0x03", dropOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.WaitStatusDropped, result.Status)
+
+	confirmOpts := opts
+	confirmOpts.Finality, confirmOpts.Confirmations = rpc.FinalityConfirmed, 3
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	result, err = rpc.WaitForTx(timeoutCtx, fake, "0x01", confirmOpts)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, rpc.WaitStatusTimeout, result.Status)
+	assert.Equal(t, int64(2), result.Confirmations)
+
+	// finality follows the finalized block of the backend once it reports one
+	finalizedOpts := opts
+	finalizedOpts.Finality = rpc.FinalityFinalized
+	fake.SetTxSequence("0x05", FakeTx{Success: true, BlockNumber: 9})
+	fake.SetFinalizedBlockNumber(9)
+	result, err = rpc.WaitForTx(ctx, fake, "0x05", finalizedOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, rpc.WaitStatusSuccess, result.Status)
+	fake.SetFinalizedBlockNumber(8)
+	timeoutCtx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	result, err = rpc.WaitForTx(timeoutCtx, fake, "0x05", finalizedOpts)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, rpc.WaitStatusTimeout, result.Status)
+
+	sim, err := NewSimulatedEvm(2)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	nonce, err := sim.Client.PendingNonceAt(ctx, sim.Address(0))
+	require.NoError(t, err)
+	to := sim.Address(1)
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    big.NewInt(1e18),
+		Gas:      21000,
+		GasPrice: big.NewInt(10e9),
+	}), types.LatestSignerForChainID(big.NewInt(SimulatedChainId)), sim.Accounts[0])
+	require.NoError(t, err)
+	require.NoError(t, sim.Client.SendTransaction(ctx, tx))
+
+	done := make(chan *rpc.WaitResult)
+	go func() {
+		confirmOpts.Confirmations = 2
+		result, err := rpc.WaitForTx(ctx, sim.Rpc, tx.Hash().Hex(), confirmOpts)
+		assert.NoError(t, err)
+		done <- result
+	}()
+	// whether the waiter polls before, between or after the blocks, it ends at 2 confirmations
+	for i := 0; i < 2; i++ {
+		_, err = sim.Commit()
+		require.NoError(t, err)
+	}
+	result = <-done
+	assert.Equal(t, rpc.WaitStatusSuccess, result.Status)
+	assert.Equal(t, int64(2), result.Confirmations)
+	assert.Equal(t, big.NewInt(21000*10e9), result.Fee)
+}
@@ -2,40 +2,36 @@ package rpc
 
 import (
 	"context"
-	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 
-	"github.com/blocto/solana-go-sdk/common"
 	"github.com/blocto/solana-go-sdk/program/metaplex/token_metadata"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
-	"github.com/near/borsh-go"
 	"github.com/owlto-dao/utils-go/loader"
 	"github.com/owlto-dao/utils-go/log"
 	sol "github.com/owlto-dao/utils-go/txn/solana"
 	"github.com/owlto-dao/utils-go/util"
 )
 
-type MetaData struct {
-	UpdateAuthority common.PublicKey
-	Mint            common.PublicKey
-	Name            string
-	Symbol          string
-	Uri             string
-}
 type SolanaRpc struct {
-	tokenInfoMgr *loader.TokenInfoManager
-	chainInfo    *loader.ChainInfo
+	tokenInfoMgr  *loader.TokenInfoManager
+	chainInfo     *loader.ChainInfo
+	tokenPrograms *sync.Map
 }
 
+var _ sol.SolanaAccountReader = (*SolanaRpc)(nil)
+
 func NewSolanaRpc(chainInfo *loader.ChainInfo) *SolanaRpc {
 	return &SolanaRpc{
-		chainInfo:    chainInfo,
-		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
+		chainInfo:     chainInfo,
+		tokenInfoMgr:  loader.NewTokenInfoManager(nil, nil),
+		tokenPrograms: &sync.Map{},
 	}
 }
 
@@ -72,18 +68,68 @@ func (w *SolanaRpc) GetStrAccountInfo(ctx context.Context, ownerAddr string) (*r
 
 }
 
-func getExtensionData(extensionType uint16, tlvData []byte) []byte {
-	extensionTypeIndex := 0
-	for extensionTypeIndex+4 <= len(tlvData) {
-		entryType := binary.LittleEndian.Uint16(tlvData[extensionTypeIndex : extensionTypeIndex+2])
-		entryLength := binary.LittleEndian.Uint16(tlvData[extensionTypeIndex+2 : extensionTypeIndex+4])
-		typeIndex := extensionTypeIndex + 4
-		if entryType == extensionType && typeIndex+int(entryLength) <= len(tlvData) {
-			return tlvData[typeIndex : typeIndex+int(entryLength)]
+// GetTokenProgram returns the token program owning the mint, either the classic token program or token-2022.
+func (w *SolanaRpc) GetTokenProgram(ctx context.Context, mintpk solana.PublicKey) (solana.PublicKey, error) {
+	if program, ok := w.tokenPrograms.Load(mintpk); ok {
+		return program.(solana.PublicKey), nil
+	}
+	rsp, err := w.GetAccountInfo(ctx, mintpk)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	program := rsp.Value.Owner
+	if !program.Equals(solana.TokenProgramID) && !program.Equals(sol.Token2022ProgramID) {
+		return solana.PublicKey{}, fmt.Errorf("%v is not a token mint, owner: %v", mintpk, program)
+	}
+	w.tokenPrograms.Store(mintpk, program)
+	return program, nil
+}
+
+// GetMintInfo reads the mint account, its owning program and the token-2022 extensions if any.
+func (w *SolanaRpc) GetMintInfo(ctx context.Context, tokenAddr string) (*sol.MintInfo, error) {
+	mintpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(tokenAddr))
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := w.GetAccountInfo(ctx, mintpk)
+	if err != nil {
+		return nil, err
+	}
+	program := rsp.Value.Owner
+	if !program.Equals(solana.TokenProgramID) && !program.Equals(sol.Token2022ProgramID) {
+		return nil, fmt.Errorf("%v is not a token mint, owner: %v", mintpk, program)
+	}
+	w.tokenPrograms.Store(mintpk, program)
+
+	var mintAccount token.Mint
+	data := rsp.GetBinary()
+	err = mintAccount.UnmarshalWithDecoder(bin.NewBorshDecoder(data))
+	if err != nil {
+		return nil, err
+	}
+
+	mintInfo := &sol.MintInfo{
+		Mint:      mintpk,
+		ProgramId: program,
+		Decimals:  mintAccount.Decimals,
+		Supply:    mintAccount.Supply,
+	}
+	if !program.Equals(sol.Token2022ProgramID) {
+		return mintInfo, nil
+	}
+
+	mintInfo.Extensions, err = sol.ParseMintExtensions(data)
+	if err != nil {
+		return nil, err
+	}
+	if mintInfo.Extensions.TransferFeeConfig != nil {
+		epochInfo, err := w.GetClient().GetEpochInfo(ctx, rpc.CommitmentConfirmed)
+		if err != nil {
+			return nil, err
 		}
-		extensionTypeIndex = typeIndex + int(entryLength)
+		mintInfo.Epoch = epochInfo.Epoch
 	}
-	return nil
+	return mintInfo, nil
 }
 
 func (w *SolanaRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
@@ -132,38 +178,25 @@ func (w *SolanaRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.
 		return loader.TokenInfo{}, err
 	}
 
-	rsp, err = w.GetAccountInfo(
-		ctx,
-		mintpk,
-	)
-	if err != nil {
-		return loader.TokenInfo{}, err
-	}
-	var mintAccount token.Mint
-	data := rsp.GetBinary()
-	decoder := bin.NewBorshDecoder(data)
-	err = mintAccount.UnmarshalWithDecoder(decoder)
+	mintInfo, err := w.GetMintInfo(ctx, tokenAddr)
 	if err != nil {
 		return loader.TokenInfo{}, err
 	}
 
-	if len(data) > 166 {
-		var metadata MetaData
-		err := borsh.Deserialize(&metadata, getExtensionData(19, []byte(data[166:])))
-		if err == nil {
-			symbol = metadata.Symbol
-			fullName = metadata.Name
-			uri = metadata.Uri
-		}
+	if mintInfo.Extensions != nil && mintInfo.Extensions.TokenMetadata != nil {
+		metadata := mintInfo.Extensions.TokenMetadata
+		symbol = metadata.Symbol
+		fullName = metadata.Name
+		uri = metadata.Uri
 	}
 
 	token := loader.TokenInfo{
 		TokenName:    symbol,
 		ChainName:    w.chainInfo.Name,
 		TokenAddress: tokenAddr,
-		Decimals:     int32(mintAccount.Decimals),
+		Decimals:     int32(mintInfo.Decimals),
 		FullName:     fullName,
-		TotalSupply:  big.NewInt(0).SetUint64(mintAccount.Supply),
+		TotalSupply:  big.NewInt(0).SetUint64(mintInfo.Supply),
 		Url:          uri,
 	}
 	w.tokenInfoMgr.AddTokenInfo(token)
@@ -184,7 +217,12 @@ func (w *SolanaRpc) GetSplAccount(ctx context.Context, ownerAddr string, tokenAd
 		return nil, err
 	}
 
-	ownerAta, err := sol.GetAtaFromPk(ownerpk, mintpk)
+	program, err := w.GetTokenProgram(ctx, mintpk)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerAta, err := sol.GetAtaWithProgram(ownerpk, mintpk, program)
 	if err != nil {
 		return nil, err
 	}
@@ -248,26 +286,79 @@ func (w *SolanaRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAdd
 	}
 }
 
+// IsTxSuccess only reports finalized txs, confirmed ones still answer "not complete"
 func (w *SolanaRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	sig, err := solana.SignatureFromBase58(hash)
+	status, err := w.GetTxStatus(ctx, hash)
+	if err == nil && status.IsIncluded() && !*status.Finalized {
+		return false, 0, fmt.Errorf("not complete: not finalized")
+	}
+	return IsTxSuccessFromStatus(status, err)
+}
+
+// GetTxStatus reports processed txs as pending, they may still be on a minority fork
+func (w *SolanaRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	sig, err := solana.SignatureFromBase58(strings.TrimSpace(hash))
 	if err != nil {
-		return false, 0, err
+		return nil, err
+	}
+	result := &TxStatus{Hash: sig.String()}
+	statuses, err := w.GetClient().GetSignatureStatuses(ctx, true, sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses.Value) == 0 || statuses.Value[0] == nil {
+		result.State = TxStateNotFound
+		return result, nil
+	}
+
+	status := statuses.Value[0]
+	result.BlockNumber = int64(status.Slot)
+	if status.ConfirmationStatus == rpc.ConfirmationStatusProcessed {
+		result.State = TxStatePending
+		return result, nil
 	}
 
-	receipt, err := w.GetClient().GetTransaction(ctx, sig, nil)
+	finalized := status.ConfirmationStatus == rpc.ConfirmationStatusFinalized
+	result.Finalized = &finalized
+	if status.Confirmations != nil {
+		result.Confirmations = int64(*status.Confirmations) + 1
+	}
+	result.State = TxStateSuccess
+	if status.Err != nil {
+		result.State = TxStateReverted
+		reason, _ := json.Marshal(status.Err)
+		result.RevertReason = string(reason)
+	}
+
+	maxVersion := uint64(0)
+	tx, err := w.GetClient().GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
 	if err != nil {
-		return false, 0, err
+		log.Warnf("%v get tx %v error %v", w.chainInfo.Name, hash, err)
+		return result, nil
 	}
-	if receipt == nil {
-		return false, 0, fmt.Errorf("get receipt failed")
+	if tx.BlockTime != nil {
+		result.BlockTime = int64(*tx.BlockTime)
 	}
-	return receipt.Meta.Err == nil, int64(receipt.Slot), nil
+	if tx.Meta != nil {
+		result.Fee = new(big.Int).SetUint64(tx.Meta.Fee)
+		if tx.Meta.ComputeUnitsConsumed != nil {
+			result.GasUsed = *tx.Meta.ComputeUnitsConsumed
+		}
+	}
+	return result, nil
 }
 
 func (w *SolanaRpc) Client() interface{} {
 	return w.chainInfo.Client
 }
 
+func (w *SolanaRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
 func (w *SolanaRpc) Backend() int32 {
 	return 3
 }
@@ -9,18 +9,31 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/NethermindEth/starknet.go/utils"
-	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/owlto-dao/utils-go/loader"
 	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/util"
 )
 
 type StarknetRpc struct {
-	chainInfo *loader.ChainInfo
+	tokenInfoMgr *loader.TokenInfoManager
+	chainInfo    *loader.ChainInfo
+}
+
+type StarknetReceipt struct {
+	Hash            string
+	ExecutionStatus rpc.TxnExecutionStatus
+	FinalityStatus  rpc.TxnFinalityStatus
+	BlockNumber     uint64
+	IsPending       bool
+	RevertReason    string
+	ActualFee       *big.Int
+	FeeUnit         rpc.FeePaymentUnit
 }
 
 func NewStarknetRpc(chainInfo *loader.ChainInfo) *StarknetRpc {
 	return &StarknetRpc{
-		chainInfo: chainInfo,
+		chainInfo:    chainInfo,
+		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
 	}
 }
 
@@ -32,74 +45,266 @@ func (w *StarknetRpc) Client() interface{} {
 	return w.chainInfo.Client
 }
 
+func (w *StarknetRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
+func (w *StarknetRpc) Call(ctx context.Context, contractAddr string, method string, calldata []*felt.Felt, blockID rpc.BlockID) ([]*felt.Felt, error) {
+	contract, err := utils.HexToFelt(strings.TrimSpace(contractAddr))
+	if err != nil {
+		return nil, err
+	}
+	if calldata == nil {
+		calldata = []*felt.Felt{}
+	}
+	tx := rpc.FunctionCall{
+		ContractAddress:    contract,
+		EntryPointSelector: utils.GetSelectorFromNameFelt(method),
+		Calldata:           calldata,
+	}
+	return w.GetClient().Call(ctx, tx, blockID)
+}
+
 func (w *StarknetRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
-	return loader.TokenInfo{}, fmt.Errorf("no impl")
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	tokenInfo, ok := w.tokenInfoMgr.GetByChainNameTokenAddr(w.chainInfo.Name, tokenAddr)
+	if ok {
+		return *tokenInfo, nil
+	}
+
+	latest := rpc.WithBlockTag("latest")
+
+	symbolRsp, err := w.Call(ctx, tokenAddr, "symbol", nil, latest)
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	symbol, err := DecodeStarknetString(symbolRsp)
+	if err != nil {
+		return loader.TokenInfo{}, fmt.Errorf("decode symbol error %w", err)
+	}
+
+	nameRsp, err := w.Call(ctx, tokenAddr, "name", nil, latest)
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	name, err := DecodeStarknetString(nameRsp)
+	if err != nil {
+		return loader.TokenInfo{}, fmt.Errorf("decode name error %w", err)
+	}
+
+	decimalsRsp, err := w.Call(ctx, tokenAddr, "decimals", nil, latest)
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	if len(decimalsRsp) == 0 {
+		return loader.TokenInfo{}, fmt.Errorf("empty decimals")
+	}
+	decimals := decimalsRsp[0].BigInt(new(big.Int))
+
+	totalSupply := big.NewInt(0)
+	totalSupplyRsp, err := w.Call(ctx, tokenAddr, "totalSupply", nil, latest)
+	if err != nil {
+		totalSupplyRsp, err = w.Call(ctx, tokenAddr, "total_supply", nil, latest)
+	}
+	if err == nil {
+		totalSupply = FeltsToU256(totalSupplyRsp)
+	}
+
+	if decimals.Sign() <= 0 || len(symbol) == 0 {
+		return loader.TokenInfo{}, fmt.Errorf("not found")
+	}
+
+	ti := loader.TokenInfo{
+		TokenName:    symbol,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     int32(decimals.Uint64()),
+		FullName:     name,
+		TotalSupply:  totalSupply,
+	}
+	w.tokenInfoMgr.AddTokenInfo(ti)
+	return ti, nil
 }
 
 func (w *StarknetRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
-	return w.GetBalance(ctx, ownerAddr, tokenAddr)
+	if blockNumber < 0 {
+		return nil, fmt.Errorf("invalid block number: %d", blockNumber)
+	}
+	return w.getBalance(ctx, ownerAddr, tokenAddr, rpc.WithBlockNumber(uint64(blockNumber)))
 }
 
 func (w *StarknetRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
-	ownerAddr = strings.TrimSpace(ownerAddr)
-	tokenAddr = strings.TrimSpace(tokenAddr)
+	return w.getBalance(ctx, ownerAddr, tokenAddr, rpc.WithBlockTag("latest"))
+}
 
-	token, err := utils.HexToFelt(tokenAddr)
+func (w *StarknetRpc) getBalance(ctx context.Context, ownerAddr string, tokenAddr string, blockID rpc.BlockID) (*big.Int, error) {
+	owner, err := utils.HexToFelt(strings.TrimSpace(ownerAddr))
 	if err != nil {
 		return nil, err
 	}
-	owner, err := utils.HexToFelt(ownerAddr)
+	rsp, err := w.Call(ctx, tokenAddr, "balanceOf", []*felt.Felt{owner}, blockID)
 	if err != nil {
 		return nil, err
 	}
-	tx := rpc.FunctionCall{
-		ContractAddress:    token,
-		EntryPointSelector: utils.GetSelectorFromNameFelt("balanceOf"),
-		Calldata:           []*felt.Felt{owner},
+	return FeltsToU256(rsp), nil
+}
+
+func (w *StarknetRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	owner, err := utils.HexToFelt(strings.TrimSpace(ownerAddr))
+	if err != nil {
+		return nil, err
 	}
-	rsp, err := w.GetClient().Call(context.Background(), tx, rpc.BlockID{Tag: "latest"})
+	spender, err := utils.HexToFelt(strings.TrimSpace(spenderAddr))
 	if err != nil {
 		return nil, err
 	}
-	if len(rsp) > 0 {
-		return rsp[0].BigInt(new(big.Int)), nil
-	} else {
-		return big.NewInt(0), nil
+	rsp, err := w.Call(ctx, tokenAddr, "allowance", []*felt.Felt{owner, spender}, rpc.WithBlockTag("latest"))
+	if err != nil {
+		return nil, err
 	}
-}
-
-func (w *StarknetRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
-	return nil, fmt.Errorf("starknet get allowance unsupport")
+	return FeltsToU256(rsp), nil
 }
 
 func (w *StarknetRpc) Backend() int32 {
 	return 2
 }
 
-func (w *StarknetRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	bhash, err := hexutil.Decode(hash)
+func (w *StarknetRpc) GetReceipt(ctx context.Context, hash string) (*StarknetReceipt, error) {
+	txHash, err := utils.HexToFelt(strings.TrimSpace(hash))
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
-	status, err := w.GetClient().GetTransactionStatus(ctx, new(felt.Felt).SetBytes(bhash))
+	receipt, err := w.GetClient().TransactionReceipt(ctx, txHash)
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
-	if status == nil {
-		return false, 0, fmt.Errorf("get status failed")
+	if receipt == nil {
+		return nil, fmt.Errorf("get receipt failed")
 	}
 
-	switch status.FinalityStatus {
-	case rpc.TxnStatus_Rejected:
-		return false, 0, nil
-	case rpc.TxnStatus_Received:
-		// ignore this status, wait for L2 confirmation
-		return false, 0, fmt.Errorf("not complete: %v", status.FinalityStatus)
-	case rpc.TxnStatus_Accepted_On_L2, rpc.TxnStatus_Accepted_On_L1:
-		return true, 0, nil //status.ExecutionStatus == rpc.TxnExecutionStatusSUCCEEDED, nil
+	var common rpc.CommonTransactionReceipt
+	isPending := false
+	switch r := receipt.(type) {
+	case rpc.InvokeTransactionReceipt:
+		common = rpc.CommonTransactionReceipt(r)
+	case rpc.DeclareTransactionReceipt:
+		common = rpc.CommonTransactionReceipt(r)
+	case rpc.L1HandlerTransactionReceipt:
+		common = rpc.CommonTransactionReceipt(r)
+	case rpc.DeployTransactionReceipt:
+		common = r.CommonTransactionReceipt
+	case rpc.DeployAccountTransactionReceipt:
+		common = r.CommonTransactionReceipt
+	case rpc.PendingInvokeTransactionReceipt:
+		common = pendingToCommon(r.PendingCommonTransactionReceiptProperties)
+		isPending = true
+	case rpc.PendingDeclareTransactionReceipt:
+		common = pendingToCommon(r.PendingCommonTransactionReceiptProperties)
+		isPending = true
+	case rpc.PendingDeployAccountTransactionReceipt:
+		common = pendingToCommon(r.PendingCommonTransactionReceiptProperties)
+		isPending = true
+	case rpc.PendingL1HandlerTransactionReceipt:
+		common = pendingToCommon(r.PendingCommonTransactionReceiptProperties)
+		isPending = true
 	default:
-		return false, 0, fmt.Errorf("unknown tx status: %v", status.FinalityStatus)
+		return nil, fmt.Errorf("unknown receipt type: %T", receipt)
+	}
+
+	fee := big.NewInt(0)
+	if common.ActualFee.Amount != nil {
+		fee = common.ActualFee.Amount.BigInt(new(big.Int))
+	}
+	return &StarknetReceipt{
+		Hash:            txHash.String(),
+		ExecutionStatus: common.ExecutionStatus,
+		FinalityStatus:  common.FinalityStatus,
+		BlockNumber:     common.BlockNumber,
+		IsPending:       isPending,
+		RevertReason:    common.RevertReason,
+		ActualFee:       fee,
+		FeeUnit:         common.ActualFee.Unit,
+	}, nil
+}
+
+func pendingToCommon(p rpc.PendingCommonTransactionReceiptProperties) rpc.CommonTransactionReceipt {
+	return rpc.CommonTransactionReceipt{
+		TransactionHash: p.TransactionHash,
+		ActualFee:       p.ActualFee,
+		ExecutionStatus: p.ExecutionStatus,
+		FinalityStatus:  p.FinalityStatus,
+		RevertReason:    p.RevertReason,
+	}
+}
+
+func (w *StarknetRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus keeps txs of the pending block pending so that the block number is known once included
+func (w *StarknetRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	receipt, err := w.GetReceipt(ctx, hash)
+	if err != nil {
+		if !isNotFoundError(err) {
+			return nil, err
+		}
+		return w.getTxStatusWithoutReceipt(ctx, hash)
+	}
+	status := &TxStatus{Hash: receipt.Hash, State: TxStatePending}
+	if receipt.IsPending {
+		return status, nil
+	}
+
+	finalized := receipt.FinalityStatus == rpc.TxnFinalityStatusAcceptedOnL1
+	status.State = TxStateSuccess
+	status.BlockNumber = int64(receipt.BlockNumber)
+	status.Fee = receipt.ActualFee
+	status.Finalized = &finalized
+	if receipt.ExecutionStatus == rpc.TxnExecutionStatusREVERTED {
+		status.State = TxStateReverted
+		status.RevertReason = receipt.RevertReason
+	}
+	blockNumber := receipt.BlockNumber
+	block, err := w.GetClient().BlockWithTxHashes(ctx, rpc.BlockID{Number: &blockNumber})
+	if err == nil {
+		if block, ok := block.(*rpc.BlockTxHashes); ok {
+			status.BlockTime = int64(block.Timestamp)
+		}
+	}
+	return status, nil
+}
+
+// getTxStatusWithoutReceipt tells txs still in the mempool from txs the sequencer rejected
+func (w *StarknetRpc) getTxStatusWithoutReceipt(ctx context.Context, hash string) (*TxStatus, error) {
+	txHash, err := utils.HexToFelt(strings.TrimSpace(hash))
+	if err != nil {
+		return nil, err
+	}
+	status := &TxStatus{Hash: txHash.String(), State: TxStateNotFound}
+	rsp, err := w.GetClient().GetTransactionStatus(ctx, txHash)
+	if err != nil {
+		if isNotFoundError(err) {
+			return status, nil
+		}
+		return nil, err
+	}
+	status.State = TxStatePending
+	if rsp.FinalityStatus == rpc.TxnStatus_Rejected {
+		status.State = TxStateDropped
+		status.RevertReason = "rejected by the sequencer"
 	}
+	return status, nil
 }
 
 func (w *StarknetRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
@@ -110,3 +315,55 @@ func (w *StarknetRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
 	}
 	return int64(blockNumber), nil
 }
+
+// FeltsToU256 decodes a call result holding either a single felt (cairo 0 tokens)
+// or a u256 split into low and high felts (cairo 1 tokens).
+func FeltsToU256(rsp []*felt.Felt) *big.Int {
+	if len(rsp) == 0 {
+		return big.NewInt(0)
+	}
+	low := rsp[0].BigInt(new(big.Int))
+	if len(rsp) < 2 {
+		return low
+	}
+	high := rsp[1].BigInt(new(big.Int))
+	return low.Add(low, high.Lsh(high, 128))
+}
+
+// DecodeStarknetString decodes a call result holding either a short string packed
+// into a single felt or a cairo ByteArray (data_len, data..., pending_word, pending_word_len).
+func DecodeStarknetString(rsp []*felt.Felt) (string, error) {
+	if len(rsp) == 0 {
+		return "", fmt.Errorf("empty string response")
+	}
+	if len(rsp) == 1 {
+		return feltToShortString(rsp[0], -1), nil
+	}
+
+	dataLen := rsp[0].BigInt(new(big.Int))
+	if !dataLen.IsUint64() || dataLen.Uint64()+3 != uint64(len(rsp)) {
+		return "", fmt.Errorf("invalid byte array length: %v", dataLen)
+	}
+	n := int(dataLen.Uint64())
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString(feltToShortString(rsp[1+i], 31))
+	}
+	pendingLen := rsp[n+2].BigInt(new(big.Int))
+	if !pendingLen.IsUint64() || pendingLen.Uint64() > 31 {
+		return "", fmt.Errorf("invalid pending word length: %v", pendingLen)
+	}
+	sb.WriteString(feltToShortString(rsp[n+1], int(pendingLen.Uint64())))
+	return sb.String(), nil
+}
+
+// feltToShortString returns the trailing size bytes of f as a string, or the
+// significant bytes when size is negative.
+func feltToShortString(f *felt.Felt, size int) string {
+	b := f.Bytes()
+	if size < 0 {
+		return strings.TrimLeft(string(b[:]), "\x00")
+	}
+	return string(b[len(b)-size:])
+}
@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeStarknetString(t *testing.T) {
+	short := new(felt.Felt).SetBytes([]byte("ETH"))
+	s, err := DecodeStarknetString([]*felt.Felt{short})
+	assert.NoError(t, err)
+	assert.Equal(t, "ETH", s)
+
+	word := new(felt.Felt).SetBytes([]byte("abcdefghijklmnopqrstuvwxyz01234"))
+	pending := new(felt.Felt).SetBytes([]byte("56"))
+	s, err = DecodeStarknetString([]*felt.Felt{utils.Uint64ToFelt(1), word, pending, utils.Uint64ToFelt(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdefghijklmnopqrstuvwxyz0123456", s)
+
+	_, err = DecodeStarknetString([]*felt.Felt{utils.Uint64ToFelt(2), word, pending, utils.Uint64ToFelt(2)})
+	assert.Error(t, err)
+}
+
+func TestFeltsToU256(t *testing.T) {
+	assert.Equal(t, big.NewInt(5), FeltsToU256([]*felt.Felt{utils.Uint64ToFelt(5)}))
+	expected := new(big.Int).Add(big.NewInt(5), new(big.Int).Lsh(big.NewInt(1), 128))
+	assert.Equal(t, expected, FeltsToU256([]*felt.Felt{utils.Uint64ToFelt(5), utils.Uint64ToFelt(1)}))
+}
@@ -0,0 +1,407 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/httputils"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+// tonDefaultJettonDecimals is the TEP-64 default when the metadata has no decimals
+const tonDefaultJettonDecimals = 9
+
+// tonMaxTraceTransactions bounds the transactions GetTxStatus follows from the hash, a jetton
+// transfer takes 4 or 5
+const tonMaxTraceTransactions = 32
+
+type tonMasterchainInfo struct {
+	Last struct {
+		Seqno int64 `json:"seqno"`
+	} `json:"last"`
+}
+
+type tonAddressInformation struct {
+	Balance string `json:"balance"`
+	Status  string `json:"status"`
+}
+
+type TonJettonWallet struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+	Owner   string `json:"owner"`
+	Jetton  string `json:"jetton"`
+}
+
+type tonJettonWallets struct {
+	JettonWallets []TonJettonWallet `json:"jetton_wallets"`
+}
+
+type tonTokenInfo struct {
+	Symbol string            `json:"symbol"`
+	Name   string            `json:"name"`
+	Extra  map[string]string `json:"extra"`
+}
+
+type tonJettonMasters struct {
+	JettonMasters []struct {
+		Address       string            `json:"address"`
+		TotalSupply   string            `json:"total_supply"`
+		JettonContent map[string]string `json:"jetton_content"`
+	} `json:"jetton_masters"`
+	Metadata map[string]struct {
+		TokenInfo []tonTokenInfo `json:"token_info"`
+	} `json:"metadata"`
+}
+
+// TonTxMessage is a message of a transaction, Destination is empty for external out messages
+type TonTxMessage struct {
+	Hash        string `json:"hash"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Bounce      bool   `json:"bounce"`
+	Bounced     bool   `json:"bounced"`
+}
+
+type TonTransaction struct {
+	Account      string         `json:"account"`
+	Hash         string         `json:"hash"`
+	Lt           string         `json:"lt"`
+	Now          int64          `json:"now"`
+	McBlockSeqno int64          `json:"mc_block_seqno"`
+	TotalFees    string         `json:"total_fees"`
+	InMsg        *TonTxMessage  `json:"in_msg"`
+	OutMsgs      []TonTxMessage `json:"out_msgs"`
+	Description  struct {
+		Aborted   bool `json:"aborted"`
+		ComputePh struct {
+			Skipped  bool `json:"skipped"`
+			Success  bool `json:"success"`
+			ExitCode int  `json:"exit_code"`
+		} `json:"compute_ph"`
+		Action *struct {
+			Success    bool `json:"success"`
+			ResultCode int  `json:"result_code"`
+		} `json:"action"`
+	} `json:"description"`
+}
+
+type tonTransactions struct {
+	Transactions []TonTransaction `json:"transactions"`
+}
+
+// TonConfig describes how to reach the toncenter v3 api of a ton chain, RpcEndPoint is the
+// api root without /api/v3 and falls back to ChainInfo.RpcEndPoint.
+type TonConfig struct {
+	RpcEndPoint string `mapstructure:"rpc_end_point"`
+	ApiKey      string `mapstructure:"api_key"`
+}
+
+var tonConfigs = loader.NewChainConfigs[TonConfig]()
+
+func SetTonConfig(chainName string, cfg TonConfig) {
+	tonConfigs.Set(chainName, cfg)
+}
+
+func GetTonConfig(chainName string) TonConfig {
+	return tonConfigs.Get(chainName)
+}
+
+// TonRpc reads a ton chain through toncenter. Tokens are jettons addressed by their master
+// contract, the gas token by a zero address.
+type TonRpc struct {
+	tokenInfoMgr *loader.TokenInfoManager
+	chainInfo    *loader.ChainInfo
+	endpoint     string
+	apiKey       string
+	client       *httputils.Client
+}
+
+// NewTonRpc uses the config registered by SetTonConfig for the chain
+func NewTonRpc(chainInfo *loader.ChainInfo) *TonRpc {
+	return NewTonRpcFromConfig(chainInfo, GetTonConfig(chainInfo.Name))
+}
+
+func NewTonRpcFromConfig(chainInfo *loader.ChainInfo, cfg TonConfig) *TonRpc {
+	endpoint := strings.TrimSpace(cfg.RpcEndPoint)
+	if endpoint == "" {
+		endpoint = chainInfo.RpcEndPoint
+	}
+	return &TonRpc{
+		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
+		chainInfo:    chainInfo,
+		endpoint:     strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		apiKey:       strings.TrimSpace(cfg.ApiKey),
+		client:       httpClientOf(chainInfo),
+	}
+}
+
+func (w *TonRpc) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	headers := map[string]string{}
+	if w.apiKey != "" {
+		headers["X-API-Key"] = w.apiKey
+	}
+	err := w.client.DoGet(ctx, w.endpoint+"/api/v3/"+path+"?"+query.Encode(), headers, result)
+	if err != nil {
+		return fmt.Errorf("toncenter get %s error: %w", path, err)
+	}
+	return nil
+}
+
+func (w *TonRpc) Client() interface{} {
+	return w.client
+}
+
+func (w *TonRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
+func (w *TonRpc) Backend() int32 {
+	return 6
+}
+
+// GetLatestBlockNumber is the seqno of the last masterchain block
+func (w *TonRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	var info tonMasterchainInfo
+	if err := w.get(ctx, "masterchainInfo", url.Values{}, &info); err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return info.Last.Seqno, nil
+}
+
+func (w *TonRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	tokenInfo, ok := w.tokenInfoMgr.GetByChainNameTokenAddr(w.chainInfo.Name, tokenAddr)
+	if ok {
+		return *tokenInfo, nil
+	}
+
+	var rsp tonJettonMasters
+	if err := w.get(ctx, "jetton/masters", url.Values{"address": {tokenAddr}, "limit": {"1"}}, &rsp); err != nil {
+		return loader.TokenInfo{}, err
+	}
+	if len(rsp.JettonMasters) == 0 {
+		return loader.TokenInfo{}, fmt.Errorf("jetton %s not found", tokenAddr)
+	}
+	master := rsp.JettonMasters[0]
+
+	// on chain content first, then the off chain metadata indexed by toncenter
+	symbol := master.JettonContent["symbol"]
+	name := master.JettonContent["name"]
+	decimalsStr := master.JettonContent["decimals"]
+	if metadata, ok := rsp.Metadata[master.Address]; ok && len(metadata.TokenInfo) > 0 {
+		indexed := metadata.TokenInfo[0]
+		if symbol == "" {
+			symbol = indexed.Symbol
+		}
+		if name == "" {
+			name = indexed.Name
+		}
+		if decimalsStr == "" {
+			decimalsStr = indexed.Extra["decimals"]
+		}
+	}
+	if symbol == "" {
+		return loader.TokenInfo{}, fmt.Errorf("jetton %s has no symbol", tokenAddr)
+	}
+
+	decimals := int64(tonDefaultJettonDecimals)
+	if decimalsStr != "" {
+		var err error
+		decimals, err = strconv.ParseInt(decimalsStr, 10, 32)
+		if err != nil {
+			return loader.TokenInfo{}, fmt.Errorf("invalid jetton decimals %s: %w", decimalsStr, err)
+		}
+	}
+	totalSupply, ok := new(big.Int).SetString(master.TotalSupply, 10)
+	if !ok {
+		totalSupply = big.NewInt(0)
+	}
+
+	ti := loader.TokenInfo{
+		TokenName:    symbol,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     int32(decimals),
+		FullName:     name,
+		TotalSupply:  totalSupply,
+	}
+	w.tokenInfoMgr.AddTokenInfo(ti)
+	return ti, nil
+}
+
+// GetJettonWallet is the jetton wallet of the owner, nil when the owner never held the jetton
+func (w *TonRpc) GetJettonWallet(ctx context.Context, ownerAddr string, tokenAddr string) (*TonJettonWallet, error) {
+	query := url.Values{
+		"owner_address":  {strings.TrimSpace(ownerAddr)},
+		"jetton_address": {strings.TrimSpace(tokenAddr)},
+		"limit":          {"1"},
+	}
+	var rsp tonJettonWallets
+	if err := w.get(ctx, "jetton/wallets", query, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.JettonWallets) == 0 {
+		return nil, nil
+	}
+	return &rsp.JettonWallets[0], nil
+}
+
+func (w *TonRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	return w.GetBalance(ctx, ownerAddr, tokenAddr)
+}
+
+func (w *TonRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	tokenAddr = strings.TrimSpace(tokenAddr)
+
+	var balance string
+	if util.IsHexStringZero(tokenAddr) {
+		var info tonAddressInformation
+		if err := w.get(ctx, "addressInformation", url.Values{"address": {ownerAddr}, "use_v2": {"false"}}, &info); err != nil {
+			return nil, err
+		}
+		balance = info.Balance
+	} else {
+		wallet, err := w.GetJettonWallet(ctx, ownerAddr, tokenAddr)
+		if err != nil {
+			return nil, err
+		}
+		if wallet == nil {
+			return big.NewInt(0), nil
+		}
+		balance = wallet.Balance
+	}
+
+	value, ok := new(big.Int).SetString(balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s of %s", balance, ownerAddr)
+	}
+	return value, nil
+}
+
+func (w *TonRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	return big.NewInt(0), fmt.Errorf("not impl")
+}
+
+// GetTransaction looks the hash up as a transaction hash, then as the hash of the message
+// starting it, which is what a wallet knows after sending an external message
+func (w *TonRpc) GetTransaction(ctx context.Context, hash string) (*TonTransaction, error) {
+	hash = strings.TrimSpace(hash)
+	var rsp tonTransactions
+	if err := w.get(ctx, "transactions", url.Values{"hash": {hash}, "limit": {"1"}}, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Transactions) == 0 {
+		return w.GetTransactionByInMessage(ctx, hash)
+	}
+	return &rsp.Transactions[0], nil
+}
+
+// GetTransactionByInMessage is the transaction processing the message, nil until it is processed
+func (w *TonRpc) GetTransactionByInMessage(ctx context.Context, msgHash string) (*TonTransaction, error) {
+	var rsp tonTransactions
+	query := url.Values{"msg_hash": {strings.TrimSpace(msgHash)}, "direction": {"in"}, "limit": {"1"}}
+	if err := w.get(ctx, "transactionsByMessage", query, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Transactions) == 0 {
+		return nil, nil
+	}
+	return &rsp.Transactions[0], nil
+}
+
+// tonTxFailure is the reason the transaction failed, empty when it succeeded
+func tonTxFailure(tx *TonTransaction) string {
+	desc := tx.Description
+	if desc.Aborted || (!desc.ComputePh.Skipped && !desc.ComputePh.Success) {
+		return fmt.Sprintf("compute exit code %d", desc.ComputePh.ExitCode)
+	}
+	if desc.Action != nil && !desc.Action.Success {
+		return fmt.Sprintf("action result code %d", desc.Action.ResultCode)
+	}
+	return ""
+}
+
+func (w *TonRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus follows the internal messages sent by the transaction of the hash, so a jetton
+// transfer failing on a jetton wallet is reverted and one still travelling is pending. Only a
+// failure on a bounceable message fails the trace, notifications and excesses are not.
+// Messages waiting to be processed are not visible, so a tx not in a block yet is not found.
+// BlockNumber is the last masterchain block of the trace and Fee the fee of the first tx.
+func (w *TonRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	hash = strings.TrimSpace(hash)
+	tx, err := w.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+	}
+
+	status := &TxStatus{
+		Hash:        hash,
+		State:       TxStateSuccess,
+		BlockNumber: tx.McBlockSeqno,
+		BlockTime:   tx.Now,
+	}
+	if fee, ok := new(big.Int).SetString(tx.TotalFees, 10); ok {
+		status.Fee = fee
+	}
+	if reason := tonTxFailure(tx); reason != "" {
+		status.State = TxStateReverted
+		status.RevertReason = reason
+		return status, nil
+	}
+
+	queue := append([]TonTxMessage(nil), tx.OutMsgs...)
+	for followed := 1; len(queue) > 0; followed++ {
+		if followed >= tonMaxTraceTransactions {
+			log.Warnf("%v tx %v trace longer than %d transactions", w.chainInfo.Name, hash, tonMaxTraceTransactions)
+			break
+		}
+		msg := queue[0]
+		queue = queue[1:]
+		if msg.Destination == "" {
+			continue
+		}
+		next, err := w.GetTransactionByInMessage(ctx, msg.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return &TxStatus{Hash: hash, State: TxStatePending, Fee: status.Fee}, nil
+		}
+		if next.McBlockSeqno > status.BlockNumber {
+			status.BlockNumber = next.McBlockSeqno
+		}
+		if reason := tonTxFailure(next); reason != "" && msg.Bounce && !msg.Bounced {
+			status.State = TxStateReverted
+			status.RevertReason = fmt.Sprintf("%s on %s", reason, next.Account)
+			return status, nil
+		}
+		queue = append(queue, next.OutMsgs...)
+	}
+	return status, nil
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTonRpc(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/masterchainInfo", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"last":{"seqno":41000000},"first":{"seqno":1}}`))
+	})
+	mux.HandleFunc("/api/v3/addressInformation", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "0:owner", r.URL.Query().Get("address"))
+		w.Write([]byte(`{"balance":"1500000000","status":"active"}`))
+	})
+	mux.HandleFunc("/api/v3/jetton/wallets", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("owner_address") == "0:empty" {
+			w.Write([]byte(`{"jetton_wallets":[]}`))
+			return
+		}
+		w.Write([]byte(`{"jetton_wallets":[{"address":"0:wallet","balance":"2500000","owner":"0:owner","jetton":"0:usdt"}]}`))
+	})
+	mux.HandleFunc("/api/v3/jetton/masters", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("address") {
+		case "0:usdt":
+			w.Write([]byte(`{"jetton_masters":[{"address":"0:USDT","total_supply":"1000","jetton_content":{"uri":"https://tether.to/usdt-ton.json","decimals":"6"}}],
+				"metadata":{"0:USDT":{"is_indexed":true,"token_info":[{"symbol":"USDT","name":"Tether USD","extra":{"decimals":"6"}}]}}}`))
+		case "0:onchain":
+			w.Write([]byte(`{"jetton_masters":[{"address":"0:ONCHAIN","total_supply":"5","jetton_content":{"symbol":"ON","name":"On Chain"}}],"metadata":{}}`))
+		default:
+			w.Write([]byte(`{"jetton_masters":[],"metadata":{}}`))
+		}
+	})
+	mux.HandleFunc("/api/v3/transactions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("hash") {
+		case "success":
+			w.Write([]byte(`{"transactions":[{"hash":"success","now":1700000000,"mc_block_seqno":41000000,"total_fees":"2000000",
+				"description":{"aborted":false,"compute_ph":{"skipped":false,"success":true,"exit_code":0},"action":{"success":true,"result_code":0}}}]}`))
+		case "failed":
+			w.Write([]byte(`{"transactions":[{"hash":"failed","now":1700000000,"mc_block_seqno":41000001,"total_fees":"1000",
+				"description":{"aborted":true,"compute_ph":{"skipped":false,"success":false,"exit_code":33}}}]}`))
+		case "jetton_failed", "jetton_pending", "jetton_success":
+			hash := r.URL.Query().Get("hash")
+			w.Write([]byte(`{"transactions":[{"account":"0:owner","hash":"` + hash + `","now":1700000000,"mc_block_seqno":41000003,"total_fees":"3000",
+				"out_msgs":[{"hash":"` + hash + `_transfer","source":"0:owner","destination":"0:wallet","bounce":true}],
+				"description":{"aborted":false,"compute_ph":{"skipped":false,"success":true,"exit_code":0},"action":{"success":true,"result_code":0}}}]}`))
+		default:
+			w.Write([]byte(`{"transactions":[]}`))
+		}
+	})
+	mux.HandleFunc("/api/v3/transactionsByMessage", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "in", r.URL.Query().Get("direction"))
+		switch r.URL.Query().Get("msg_hash") {
+		case "message":
+			w.Write([]byte(`{"transactions":[{"hash":"tx","now":1700000000,"mc_block_seqno":41000002,"total_fees":"1000",
+				"description":{"aborted":false,"compute_ph":{"skipped":false,"success":true,"exit_code":0}}}]}`))
+		case "jetton_failed_transfer":
+			// the jetton wallet of the sender has not enough balance, the transfer bounces back
+			w.Write([]byte(`{"transactions":[{"account":"0:wallet","hash":"wallet_tx","now":1700000005,"mc_block_seqno":41000004,"total_fees":"500",
+				"in_msg":{"hash":"jetton_failed_transfer","source":"0:owner","destination":"0:wallet","bounce":true},
+				"out_msgs":[{"hash":"bounce","source":"0:wallet","destination":"0:owner","bounce":false,"bounced":true}],
+				"description":{"aborted":true,"compute_ph":{"skipped":false,"success":false,"exit_code":47}}}]}`))
+		case "jetton_success_transfer":
+			w.Write([]byte(`{"transactions":[{"account":"0:wallet","hash":"wallet_tx","now":1700000005,"mc_block_seqno":41000004,"total_fees":"500",
+				"out_msgs":[{"hash":"internal_transfer","source":"0:wallet","destination":"0:receiver_wallet","bounce":true},{"hash":"log","source":"0:wallet","destination":""}],
+				"description":{"aborted":false,"compute_ph":{"skipped":false,"success":true,"exit_code":0},"action":{"success":true,"result_code":0}}}]}`))
+		case "internal_transfer":
+			w.Write([]byte(`{"transactions":[{"account":"0:receiver_wallet","hash":"receiver_tx","now":1700000010,"mc_block_seqno":41000006,"total_fees":"500",
+				"out_msgs":[{"hash":"notify","source":"0:receiver_wallet","destination":"0:receiver","bounce":false}],
+				"description":{"aborted":false,"compute_ph":{"skipped":false,"success":true,"exit_code":0},"action":{"success":true,"result_code":0}}}]}`))
+		case "notify":
+			// a receiver failing on the notification does not undo the transfer
+			w.Write([]byte(`{"transactions":[{"account":"0:receiver","hash":"notify_tx","now":1700000015,"mc_block_seqno":41000007,"total_fees":"500",
+				"description":{"aborted":true,"compute_ph":{"skipped":false,"success":false,"exit_code":9}}}]}`))
+		default:
+			w.Write([]byte(`{"transactions":[]}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chainInfo := &loader.ChainInfo{Name: "TonMainnet", Backend: loader.TonBackend, RpcEndPoint: server.URL, GasTokenName: "TON", GasTokenDecimal: 9}
+	SetTonConfig("TonMainnet", TonConfig{ApiKey: "key"})
+	t.Cleanup(func() { tonConfigs.Delete("TonMainnet") })
+	w, err := GetRpc(chainInfo)
+	require.NoError(t, err)
+	tonRpc := w.(*TonRpc)
+
+	seqno, err := tonRpc.GetLatestBlockNumber(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(41000000), seqno)
+
+	balance, err := tonRpc.GetBalance(context.TODO(), "0:owner", "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1500000000), balance)
+
+	balance, err = tonRpc.GetBalance(context.TODO(), "0:owner", "0:usdt")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2500000), balance)
+
+	balance, err = tonRpc.GetBalance(context.TODO(), "0:empty", "0:usdt")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), balance)
+
+	info, err := tonRpc.GetTokenInfo(context.TODO(), "0:usdt")
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", info.TokenName)
+	assert.Equal(t, "Tether USD", info.FullName)
+	assert.Equal(t, int32(6), info.Decimals)
+
+	info, err = tonRpc.GetTokenInfo(context.TODO(), "0:onchain")
+	assert.NoError(t, err)
+	assert.Equal(t, "ON", info.TokenName)
+	assert.Equal(t, int32(9), info.Decimals)
+
+	_, err = tonRpc.GetTokenInfo(context.TODO(), "0:unknown")
+	assert.Error(t, err)
+
+	ok, block, err := tonRpc.IsTxSuccess(context.TODO(), "success")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(41000000), block)
+
+	status, err := tonRpc.GetTxStatus(context.TODO(), "failed")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateReverted, status.State)
+	assert.Equal(t, "compute exit code 33", status.RevertReason)
+	assert.Equal(t, big.NewInt(1000), status.Fee)
+
+	status, err = tonRpc.GetTxStatus(context.TODO(), "message")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateSuccess, status.State)
+	assert.Equal(t, int64(41000002), status.BlockNumber)
+
+	status, err = tonRpc.GetTxStatus(context.TODO(), "jetton_failed")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateReverted, status.State)
+	assert.Equal(t, "compute exit code 47 on 0:wallet", status.RevertReason)
+	assert.Equal(t, int64(41000004), status.BlockNumber)
+	assert.Equal(t, big.NewInt(3000), status.Fee)
+	ok, _, err = tonRpc.IsTxSuccess(context.TODO(), "jetton_failed")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	status, err = tonRpc.GetTxStatus(context.TODO(), "jetton_pending")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStatePending, status.State)
+	_, _, err = tonRpc.IsTxSuccess(context.TODO(), "jetton_pending")
+	assert.ErrorContains(t, err, "not complete")
+
+	status, err = tonRpc.GetTxStatus(context.TODO(), "jetton_success")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateSuccess, status.State)
+	assert.Equal(t, int64(41000007), status.BlockNumber)
+
+	_, _, err = tonRpc.IsTxSuccess(context.TODO(), "unknown")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
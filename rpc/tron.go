@@ -0,0 +1,383 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/owlto-dao/utils-go/abi/erc20"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/httputils"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+type tronBlock struct {
+	BlockID     string `json:"blockID"`
+	BlockHeader struct {
+		RawData struct {
+			Number    int64 `json:"number"`
+			Timestamp int64 `json:"timestamp"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+}
+
+type tronAccount struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+}
+
+// TronConstantResult is the answer of triggerconstantcontract, EnergyUsed is what the call
+// would burn if sent
+type TronConstantResult struct {
+	Result struct {
+		Result  bool   `json:"result"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"result"`
+	EnergyUsed     int64    `json:"energy_used"`
+	ConstantResult []string `json:"constant_result"`
+}
+
+type TronTransactionInfo struct {
+	Id             string `json:"id"`
+	Fee            int64  `json:"fee"`
+	BlockNumber    int64  `json:"blockNumber"`
+	BlockTimeStamp int64  `json:"blockTimeStamp"`
+	Receipt        struct {
+		Result           string `json:"result"`
+		EnergyUsageTotal uint64 `json:"energy_usage_total"`
+		NetUsage         uint64 `json:"net_usage"`
+	} `json:"receipt"`
+	Result     string `json:"result"`
+	ResMessage string `json:"resMessage"`
+}
+
+type tronTransaction struct {
+	TxID string `json:"txID"`
+}
+
+type tronChainParameters struct {
+	ChainParameter []struct {
+		Key   string `json:"key"`
+		Value int64  `json:"value"`
+	} `json:"chainParameter"`
+}
+
+// TronConfig holds the api key of the tron http api, sent as TRON-PRO-API-KEY as trongrid
+// expects, RpcEndPoint falls back to ChainInfo.RpcEndPoint.
+type TronConfig struct {
+	RpcEndPoint string `mapstructure:"rpc_end_point"`
+	ApiKey      string `mapstructure:"api_key"`
+}
+
+var tronConfigs = loader.NewChainConfigs[TronConfig]()
+
+func SetTronConfig(chainName string, cfg TronConfig) {
+	tronConfigs.Set(chainName, cfg)
+}
+
+func GetTronConfig(chainName string) TronConfig {
+	return tronConfigs.Get(chainName)
+}
+
+// TronRpc reads a tron chain through the http api of a full node. Addresses are base58,
+// tokens are TRC20 contracts and the gas token TRX is the zero address.
+type TronRpc struct {
+	tokenInfoMgr *loader.TokenInfoManager
+	chainInfo    *loader.ChainInfo
+	endpoint     string
+	apiKey       string
+	client       *httputils.Client
+}
+
+// NewTronRpc uses the config registered by SetTronConfig for the chain
+func NewTronRpc(chainInfo *loader.ChainInfo) *TronRpc {
+	return NewTronRpcFromConfig(chainInfo, GetTronConfig(chainInfo.Name))
+}
+
+func NewTronRpcFromConfig(chainInfo *loader.ChainInfo, cfg TronConfig) *TronRpc {
+	endpoint := strings.TrimSpace(cfg.RpcEndPoint)
+	if endpoint == "" {
+		endpoint = chainInfo.RpcEndPoint
+	}
+	return &TronRpc{
+		tokenInfoMgr: loader.NewTokenInfoManager(nil, nil),
+		chainInfo:    chainInfo,
+		endpoint:     strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		apiKey:       strings.TrimSpace(cfg.ApiKey),
+		client:       httpClientOf(chainInfo),
+	}
+}
+
+func (w *TronRpc) GetClient() *httputils.Client {
+	return w.client
+}
+
+func (w *TronRpc) Client() interface{} {
+	return w.client
+}
+
+func (w *TronRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
+func (w *TronRpc) Backend() int32 {
+	return 9
+}
+
+func (w *TronRpc) post(ctx context.Context, path string, data map[string]interface{}, result interface{}) error {
+	headers := map[string]string{}
+	if w.apiKey != "" {
+		headers["TRON-PRO-API-KEY"] = w.apiKey
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	err := w.GetClient().DoPost(ctx, w.endpoint+path, data, headers, result)
+	if err != nil {
+		return fmt.Errorf("tron post %s error: %w", path, err)
+	}
+	return nil
+}
+
+// TronAbiCall encodes a method of the erc20 abi, which TRC20 shares, as the function selector
+// and hex parameter of the tron http api. Address arguments are base58 strings.
+func TronAbiCall(method string, args ...interface{}) (string, string, error) {
+	erc20Abi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return "", "", err
+	}
+	abiMethod, ok := erc20Abi.Methods[method]
+	if !ok {
+		return "", "", fmt.Errorf("unknown trc20 method %s", method)
+	}
+	packArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if addr, ok := arg.(string); ok {
+			evmAddr, err := address.TronToEvm(addr)
+			if err != nil {
+				return "", "", err
+			}
+			arg = common.HexToAddress(evmAddr)
+		}
+		packArgs = append(packArgs, arg)
+	}
+	data, err := erc20Abi.Pack(method, packArgs...)
+	if err != nil {
+		return "", "", err
+	}
+	return abiMethod.Sig, hex.EncodeToString(data[4:]), nil
+}
+
+func (w *TronRpc) TriggerConstantContract(ctx context.Context, ownerAddr string, contractAddr string, selector string, parameter string) (*TronConstantResult, error) {
+	data := map[string]interface{}{
+		"owner_address":     strings.TrimSpace(ownerAddr),
+		"contract_address":  strings.TrimSpace(contractAddr),
+		"function_selector": selector,
+		"parameter":         parameter,
+		"visible":           true,
+	}
+	var result TronConstantResult
+	if err := w.post(ctx, "/wallet/triggerconstantcontract", data, &result); err != nil {
+		return nil, err
+	}
+	if !result.Result.Result {
+		message, err := hex.DecodeString(result.Result.Message)
+		if err != nil {
+			message = []byte(result.Result.Message)
+		}
+		return nil, fmt.Errorf("trigger %s of %s failed %s: %s", selector, contractAddr, result.Result.Code, message)
+	}
+	return &result, nil
+}
+
+// CallTrc20 runs a constant method of the token and unpacks its outputs
+func (w *TronRpc) CallTrc20(ctx context.Context, ownerAddr string, tokenAddr string, method string, args ...interface{}) ([]interface{}, error) {
+	selector, parameter, err := TronAbiCall(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := w.TriggerConstantContract(ctx, ownerAddr, tokenAddr, selector, parameter)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.ConstantResult) == 0 {
+		return nil, fmt.Errorf("%s of %s returned nothing", method, tokenAddr)
+	}
+	data, err := hex.DecodeString(result.ConstantResult[0])
+	if err != nil {
+		return nil, err
+	}
+	erc20Abi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return erc20Abi.Unpack(method, data)
+}
+
+func (w *TronRpc) callTrc20BigInt(ctx context.Context, ownerAddr string, tokenAddr string, method string, args ...interface{}) (*big.Int, error) {
+	outputs, err := w.CallTrc20(ctx, ownerAddr, tokenAddr, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	value := *abi.ConvertType(outputs[0], new(*big.Int)).(**big.Int)
+	return value, nil
+}
+
+func (w *TronRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	var block tronBlock
+	if err := w.post(ctx, "/wallet/getnowblock", nil, &block); err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return block.BlockHeader.RawData.Number, nil
+}
+
+func (w *TronRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if util.IsHexStringZero(tokenAddr) {
+		return loader.TokenInfo{
+			TokenName:    w.chainInfo.GasTokenName,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     w.chainInfo.GasTokenDecimal,
+			FullName:     w.chainInfo.AliasName,
+			TotalSupply:  big.NewInt(0),
+			Url:          w.chainInfo.ExplorerUrl,
+		}, nil
+	}
+	tokenInfo, ok := w.tokenInfoMgr.GetByChainNameTokenAddr(w.chainInfo.Name, tokenAddr)
+	if ok {
+		return *tokenInfo, nil
+	}
+
+	// constant calls need an owner, the token itself will do
+	symbol, err := w.CallTrc20(ctx, tokenAddr, tokenAddr, "symbol")
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	name, err := w.CallTrc20(ctx, tokenAddr, tokenAddr, "name")
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	decimals, err := w.CallTrc20(ctx, tokenAddr, tokenAddr, "decimals")
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	totalSupply, err := w.callTrc20BigInt(ctx, tokenAddr, tokenAddr, "totalSupply")
+	if err != nil {
+		totalSupply = big.NewInt(0)
+	}
+
+	ti := loader.TokenInfo{
+		TokenName:    *abi.ConvertType(symbol[0], new(string)).(*string),
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     int32(*abi.ConvertType(decimals[0], new(uint8)).(*uint8)),
+		FullName:     *abi.ConvertType(name[0], new(string)).(*string),
+		TotalSupply:  totalSupply,
+	}
+	if ti.TokenName == "" {
+		return loader.TokenInfo{}, fmt.Errorf("not found")
+	}
+	w.tokenInfoMgr.AddTokenInfo(ti)
+	return ti, nil
+}
+
+func (w *TronRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	return w.GetBalance(ctx, ownerAddr, tokenAddr)
+}
+
+func (w *TronRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	tokenAddr = strings.TrimSpace(tokenAddr)
+
+	if util.IsHexStringZero(tokenAddr) {
+		// an account not activated yet is returned empty
+		var account tronAccount
+		if err := w.post(ctx, "/wallet/getaccount", map[string]interface{}{"address": ownerAddr, "visible": true}, &account); err != nil {
+			return nil, err
+		}
+		return big.NewInt(account.Balance), nil
+	}
+	return w.callTrc20BigInt(ctx, ownerAddr, tokenAddr, "balanceOf", ownerAddr)
+}
+
+func (w *TronRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	spenderAddr = strings.TrimSpace(spenderAddr)
+	return w.callTrc20BigInt(ctx, ownerAddr, tokenAddr, "allowance", ownerAddr, spenderAddr)
+}
+
+// GetEnergyPrice is the sun burnt per unit of energy when the sender has no staked energy
+func (w *TronRpc) GetEnergyPrice(ctx context.Context) (int64, error) {
+	var params tronChainParameters
+	if err := w.post(ctx, "/wallet/getchainparameters", nil, &params); err != nil {
+		return 0, err
+	}
+	for _, param := range params.ChainParameter {
+		if param.Key == "getEnergyFee" {
+			return param.Value, nil
+		}
+	}
+	return 0, fmt.Errorf("no getEnergyFee chain parameter")
+}
+
+// GetTransactionInfo is nil until the tx is in a block
+func (w *TronRpc) GetTransactionInfo(ctx context.Context, hash string) (*TronTransactionInfo, error) {
+	var info TronTransactionInfo
+	if err := w.post(ctx, "/wallet/gettransactioninfobyid", map[string]interface{}{"value": hash}, &info); err != nil {
+		return nil, err
+	}
+	if info.Id == "" {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (w *TronRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus reports a tx known to the node without info as pending
+func (w *TronRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	hash = strings.TrimPrefix(strings.TrimSpace(hash), "0x")
+	info, err := w.GetTransactionInfo(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		var tx tronTransaction
+		if err := w.post(ctx, "/wallet/gettransactionbyid", map[string]interface{}{"value": hash}, &tx); err != nil {
+			return nil, err
+		}
+		if tx.TxID == "" {
+			return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+		}
+		return &TxStatus{Hash: hash, State: TxStatePending}, nil
+	}
+
+	status := &TxStatus{
+		Hash:        hash,
+		State:       TxStateSuccess,
+		BlockNumber: info.BlockNumber,
+		BlockTime:   info.BlockTimeStamp / 1000,
+		GasUsed:     info.Receipt.EnergyUsageTotal,
+		Fee:         big.NewInt(info.Fee),
+	}
+	// plain TRX transfers have no receipt result
+	if info.Result == "FAILED" || (info.Receipt.Result != "" && info.Receipt.Result != "SUCCESS") {
+		status.State = TxStateReverted
+		status.RevertReason = info.Receipt.Result
+		if message, err := hex.DecodeString(info.ResMessage); err == nil && len(message) > 0 {
+			status.RevertReason = fmt.Sprintf("%s: %s", info.Receipt.Result, message)
+		}
+	}
+	return status, nil
+}
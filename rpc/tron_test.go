@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/owlto-dao/utils-go/abi/erc20"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTronUsdt = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+
+func TestTronRpc(t *testing.T) {
+	owner, err := address.TronHexToBase58("41" + strings.Repeat("11", 20))
+	require.NoError(t, err)
+	spender, err := address.TronHexToBase58("41" + strings.Repeat("22", 20))
+	require.NoError(t, err)
+	erc20Abi, err := erc20.Erc20MetaData.GetAbi()
+	require.NoError(t, err)
+	output := func(method string, value interface{}) map[string]interface{} {
+		data, err := erc20Abi.Methods[method].Outputs.Pack(value)
+		require.NoError(t, err)
+		return map[string]interface{}{"result": map[string]bool{"result": true}, "energy_used": 0, "constant_result": []string{hex.EncodeToString(data)}}
+	}
+
+	mux := http.NewServeMux()
+	handle := func(path string, handler func(req map[string]interface{}) interface{}) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "key", r.Header.Get("TRON-PRO-API-KEY"))
+			var req map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_ = json.NewEncoder(w).Encode(handler(req))
+		})
+	}
+	handle("/wallet/getnowblock", func(req map[string]interface{}) interface{} {
+		return map[string]interface{}{"blockID": "00", "block_header": map[string]interface{}{"raw_data": map[string]int64{"number": 62000000, "timestamp": 1}}}
+	})
+	handle("/wallet/getaccount", func(req map[string]interface{}) interface{} {
+		if req["address"] == owner {
+			return map[string]interface{}{"address": owner, "balance": 3_000_000}
+		}
+		return map[string]interface{}{}
+	})
+	handle("/wallet/triggerconstantcontract", func(req map[string]interface{}) interface{} {
+		assert.Equal(t, testTronUsdt, req["contract_address"])
+		switch req["function_selector"] {
+		case "symbol()":
+			return output("symbol", "USDT")
+		case "name()":
+			return output("name", "Tether USD")
+		case "decimals()":
+			return output("decimals", uint8(6))
+		case "totalSupply()":
+			return output("totalSupply", big.NewInt(1000))
+		case "balanceOf(address)":
+			assert.Equal(t, hex.EncodeToString(common.LeftPadBytes(common.FromHex(strings.Repeat("11", 20)), 32)), req["parameter"])
+			return output("balanceOf", big.NewInt(2_500_000))
+		case "allowance(address,address)":
+			return output("allowance", big.NewInt(7))
+		}
+		return map[string]interface{}{"result": map[string]interface{}{"result": false, "code": "CONTRACT_VALIDATE_ERROR", "message": hex.EncodeToString([]byte("unknown"))}}
+	})
+	handle("/wallet/gettransactioninfobyid", func(req map[string]interface{}) interface{} {
+		switch req["value"] {
+		case "aa":
+			return map[string]interface{}{"id": "aa", "fee": 345000, "blockNumber": 61999990, "blockTimeStamp": 1700000000000,
+				"receipt": map[string]interface{}{"result": "SUCCESS", "energy_usage_total": 14650}}
+		case "bb":
+			return map[string]interface{}{"id": "bb", "fee": 1000, "blockNumber": 61999991, "blockTimeStamp": 1700000003000,
+				"receipt": map[string]interface{}{"result": "REVERT"}, "result": "FAILED", "resMessage": hex.EncodeToString([]byte("REVERT opcode executed"))}
+		case "cc":
+			return map[string]interface{}{"id": "cc", "blockNumber": 61999992, "receipt": map[string]interface{}{"net_usage": 268}}
+		}
+		return map[string]interface{}{}
+	})
+	handle("/wallet/gettransactionbyid", func(req map[string]interface{}) interface{} {
+		if req["value"] == "dd" {
+			return map[string]interface{}{"txID": "dd"}
+		}
+		return map[string]interface{}{}
+	})
+	handle("/wallet/getchainparameters", func(req map[string]interface{}) interface{} {
+		return map[string]interface{}{"chainParameter": []map[string]interface{}{{"key": "getTransactionFee", "value": 1000}, {"key": "getEnergyFee", "value": 210}}}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chainInfo := &loader.ChainInfo{Name: "TronMainnet", Backend: loader.TronBackend, RpcEndPoint: server.URL, GasTokenName: "TRX", GasTokenDecimal: 6}
+	SetTronConfig("TronMainnet", TronConfig{ApiKey: "key"})
+	t.Cleanup(func() { tronConfigs.Delete("TronMainnet") })
+	w, err := GetRpc(chainInfo)
+	require.NoError(t, err)
+	tronRpc := w.(*TronRpc)
+
+	number, err := tronRpc.GetLatestBlockNumber(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(62000000), number)
+
+	balance, err := tronRpc.GetBalance(context.TODO(), owner, "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(3_000_000), balance)
+
+	balance, err = tronRpc.GetBalance(context.TODO(), spender, "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), balance)
+
+	balance, err = tronRpc.GetBalance(context.TODO(), owner, testTronUsdt)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2_500_000), balance)
+
+	allowance, err := tronRpc.GetAllowance(context.TODO(), owner, testTronUsdt, spender)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), allowance)
+
+	info, err := tronRpc.GetTokenInfo(context.TODO(), testTronUsdt)
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", info.TokenName)
+	assert.Equal(t, "Tether USD", info.FullName)
+	assert.Equal(t, int32(6), info.Decimals)
+	assert.Equal(t, big.NewInt(1000), info.TotalSupply)
+
+	price, err := tronRpc.GetEnergyPrice(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(210), price)
+
+	ok, block, err := tronRpc.IsTxSuccess(context.TODO(), "0xaa")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(61999990), block)
+
+	status, err := tronRpc.GetTxStatus(context.TODO(), "bb")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateReverted, status.State)
+	assert.Equal(t, "REVERT: REVERT opcode executed", status.RevertReason)
+
+	status, err = tronRpc.GetTxStatus(context.TODO(), "cc")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateSuccess, status.State)
+
+	status, err = tronRpc.GetTxStatus(context.TODO(), "dd")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStatePending, status.State)
+
+	_, _, err = tronRpc.IsTxSuccess(context.TODO(), "ee")
+	assert.ErrorIs(t, err, ErrTxNotFound)
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrTxNotFound is go-ethereum's NotFound, so callers matching it keep working on every backend
+var ErrTxNotFound = ethereum.NotFound
+
+type TxState int
+
+const (
+	// TxStatePending is known to the node but not in a block yet
+	TxStatePending TxState = iota
+	TxStateSuccess
+	// TxStateReverted is in a block but failed, the fee is paid
+	TxStateReverted
+	// TxStateNotFound is unknown to the node, it may not be propagated yet or be long gone
+	TxStateNotFound
+	// TxStateDropped was rejected by the chain and never lands in a block
+	TxStateDropped
+)
+
+func (s TxState) String() string {
+	switch s {
+	case TxStatePending:
+		return "pending"
+	case TxStateSuccess:
+		return "success"
+	case TxStateReverted:
+		return "reverted"
+	case TxStateNotFound:
+		return "not found"
+	case TxStateDropped:
+		return "dropped"
+	}
+	return fmt.Sprintf("TxState(%d)", int(s))
+}
+
+type TxStatus struct {
+	Hash  string
+	State TxState
+	// BlockNumber is the block, slot or height holding the tx, 0 until it is included
+	BlockNumber int64
+	// BlockTime is the unix time of the block, 0 when unknown
+	BlockTime int64
+	// GasUsed is 0 on backends without a gas notion
+	GasUsed uint64
+	// Fee is in the smallest unit of the gas token, nil when the backend does not report it
+	Fee          *big.Int
+	RevertReason string
+	// Confirmations counts the tx block, 0 when the backend does not report it
+	Confirmations int64
+	// Finalized is nil when the backend has no finality notion
+	Finalized *bool
+}
+
+// IsIncluded tells whether the tx is in a block, successful or not
+func (s *TxStatus) IsIncluded() bool {
+	return s.State == TxStateSuccess || s.State == TxStateReverted
+}
+
+// IsTxSuccessFromStatus maps a GetTxStatus result to the IsTxSuccess contract: unknown txs
+// answer ErrTxNotFound, pending ones "not complete" wrapping ErrTxNotFound as evm nodes did
+// without a receipt, dropped ones fail without error like a rejected starknet tx.
+func IsTxSuccessFromStatus(status *TxStatus, err error) (bool, int64, error) {
+	if err != nil {
+		return false, 0, err
+	}
+	switch status.State {
+	case TxStatePending:
+		return false, 0, fmt.Errorf("not complete: pending: %w", ErrTxNotFound)
+	case TxStateNotFound:
+		return false, 0, fmt.Errorf("%w: %s", ErrTxNotFound, status.Hash)
+	case TxStateDropped:
+		return false, 0, nil
+	}
+	return status.State == TxStateSuccess, status.BlockNumber, nil
+}
+
+func isNotFoundError(err error) bool {
+	if errors.Is(err, ethereum.NotFound) || errors.Is(err, solrpc.ErrNotFound) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "not_found")
+}
@@ -0,0 +1,245 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+type Finality int
+
+const (
+	// FinalityIncluded waits for the tx to be in a block
+	FinalityIncluded Finality = iota
+	// FinalityConfirmed waits for WaitOptions.Confirmations blocks on top of the tx block included
+	FinalityConfirmed
+	// FinalityFinalized waits for the chain's own finality: the evm finalized tag, the solana
+	// finalized commitment, starknet ACCEPTED_ON_L1 or a verified zkslite block. Backends
+	// without such a notion fall back to FinalityConfirmed.
+	FinalityFinalized
+)
+
+type WaitStatus int
+
+const (
+	WaitStatusSuccess WaitStatus = iota
+	WaitStatusFailed
+	WaitStatusDropped
+	WaitStatusTimeout
+)
+
+func (s WaitStatus) String() string {
+	switch s {
+	case WaitStatusSuccess:
+		return "success"
+	case WaitStatusFailed:
+		return "failed"
+	case WaitStatusDropped:
+		return "dropped"
+	case WaitStatusTimeout:
+		return "timeout"
+	}
+	return fmt.Sprintf("WaitStatus(%d)", int(s))
+}
+
+type WaitOptions struct {
+	Finality Finality
+	// Confirmations is the number of blocks including the tx block, defaults to 1
+	Confirmations int64
+	// PollInterval defaults to half the chain's BlockInterval, it backs off up to MaxPollInterval
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// DroppedAfter reports the tx dropped once the node has not known it for that long, 0 waits forever
+	DroppedAfter time.Duration
+}
+
+type WaitResult struct {
+	Status        WaitStatus
+	BlockNumber   int64
+	Confirmations int64
+	// Fee is the fee paid in the smallest unit of the gas token, nil when the backend does not report it
+	Fee     *big.Int
+	Elapsed time.Duration
+}
+
+type chainInfoGetter interface {
+	GetChainInfo() *loader.ChainInfo
+}
+
+type unwrapper interface {
+	Unwrap() Rpc
+}
+
+// finalizedBlockGetter is implemented by backends telling finality by a finalized block
+// rather than per tx, like the evm finalized tag
+type finalizedBlockGetter interface {
+	GetFinalizedBlockNumber(ctx context.Context) (int64, error)
+}
+
+func getChainInfo(w Rpc) *loader.ChainInfo {
+	for inner := w; inner != nil; {
+		if getter, ok := inner.(chainInfoGetter); ok {
+			return getter.GetChainInfo()
+		}
+		u, ok := inner.(unwrapper)
+		if !ok {
+			break
+		}
+		inner = u.Unwrap()
+	}
+	return nil
+}
+
+func (opts WaitOptions) withDefaults(w Rpc) WaitOptions {
+	if opts.Confirmations <= 0 {
+		opts.Confirmations = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 3 * time.Second
+		if chainInfo := getChainInfo(w); chainInfo != nil && chainInfo.BlockInterval > 0 {
+			opts.PollInterval = time.Duration(chainInfo.BlockInterval) * time.Second / 2
+		}
+		if opts.PollInterval < 500*time.Millisecond {
+			opts.PollInterval = 500 * time.Millisecond
+		}
+	}
+	if opts.MaxPollInterval < opts.PollInterval {
+		opts.MaxPollInterval = 8 * opts.PollInterval
+	}
+	return opts
+}
+
+// subscribeHeads wakes the waiter on new evm heads when the client supports subscriptions,
+// the returned channel is nil otherwise.
+func subscribeHeads(ctx context.Context, w Rpc) (<-chan *ethtypes.Header, func()) {
+	for inner := w; inner != nil; {
+		if evm, ok := inner.(*EvmRpc); ok {
+			heads := make(chan *ethtypes.Header, 16)
+			sub, err := evm.GetClient().SubscribeNewHead(ctx, heads)
+			if err != nil {
+				return nil, func() {}
+			}
+			return heads, sub.Unsubscribe
+		}
+		u, ok := inner.(unwrapper)
+		if !ok {
+			break
+		}
+		inner = u.Unwrap()
+	}
+	return nil, func() {}
+}
+
+// WaitForTx polls the tx until it reaches the requested finality, fails or is dropped.
+// A done ctx returns the last known state with WaitStatusTimeout and the ctx error.
+func WaitForTx(ctx context.Context, w Rpc, hash string, opts WaitOptions) (*WaitResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults(w)
+	heads, unsubscribe := subscribeHeads(ctx, w)
+	defer unsubscribe()
+
+	result := &WaitResult{Status: WaitStatusTimeout}
+	interval := opts.PollInterval
+	var missingSince time.Time
+	for {
+		status, err := w.GetTxStatus(ctx, hash)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warnf("wait tx %v error %v", hash, err)
+			}
+		} else if status.State == TxStateNotFound {
+			if missingSince.IsZero() {
+				missingSince = time.Now()
+			}
+			if opts.DroppedAfter > 0 && time.Since(missingSince) >= opts.DroppedAfter {
+				result.Status = WaitStatusDropped
+				result.Elapsed = time.Since(start)
+				return result, nil
+			}
+		} else {
+			missingSince = time.Time{}
+			switch status.State {
+			case TxStateDropped:
+				result.Status = WaitStatusDropped
+				result.Elapsed = time.Since(start)
+				return result, nil
+			case TxStateReverted:
+				result.BlockNumber = status.BlockNumber
+				result.Fee = status.Fee
+				result.Status = WaitStatusFailed
+				result.Elapsed = time.Since(start)
+				return result, nil
+			case TxStateSuccess:
+				result.BlockNumber = status.BlockNumber
+				result.Fee = status.Fee
+				done, err := reachedFinality(ctx, w, status, opts, result)
+				if err != nil && ctx.Err() == nil {
+					log.Warnf("wait tx %v finality error %v", hash, err)
+				}
+				if done {
+					result.Status = WaitStatusSuccess
+					result.Elapsed = time.Since(start)
+					return result, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Elapsed = time.Since(start)
+			return result, ctx.Err()
+		case <-heads:
+		case <-time.After(interval):
+		}
+		if interval = interval * 3 / 2; interval > opts.MaxPollInterval {
+			interval = opts.MaxPollInterval
+		}
+	}
+}
+
+// getFinalizedBlockNumber asks the first backend of the decorator chain that knows a finalized
+// block, ok is false when none does or the node does not support it
+func getFinalizedBlockNumber(ctx context.Context, w Rpc) (int64, bool) {
+	for inner := w; inner != nil; {
+		if getter, ok := inner.(finalizedBlockGetter); ok {
+			finalized, err := getter.GetFinalizedBlockNumber(ctx)
+			return finalized, err == nil
+		}
+		u, ok := inner.(unwrapper)
+		if !ok {
+			break
+		}
+		inner = u.Unwrap()
+	}
+	return 0, false
+}
+
+func reachedFinality(ctx context.Context, w Rpc, status *TxStatus, opts WaitOptions, result *WaitResult) (bool, error) {
+	if opts.Finality == FinalityIncluded {
+		return true, nil
+	}
+	if opts.Finality == FinalityFinalized {
+		if status.Finalized != nil {
+			return *status.Finalized, nil
+		}
+		if finalized, ok := getFinalizedBlockNumber(ctx, w); ok {
+			return status.BlockNumber <= finalized, nil
+		}
+	}
+
+	confirmations := status.Confirmations
+	if confirmations == 0 {
+		latest, err := w.GetLatestBlockNumber(ctx)
+		if err != nil {
+			return false, err
+		}
+		confirmations = latest - status.BlockNumber + 1
+	}
+	result.Confirmations = confirmations
+	return confirmations >= opts.Confirmations, nil
+}
@@ -2,22 +2,95 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
 	"github.com/owlto-dao/utils-go/network"
 	"github.com/owlto-dao/utils-go/util"
 )
 
+// zksliteTokenReloadInterval bounds how often an unknown token address reloads the token list
+const zksliteTokenReloadInterval = time.Minute
+
+type zksliteRequest struct {
+	JsonRpc string        `json:"jsonrpc"`
+	Id      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type zksliteError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+type zksliteResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *zksliteError   `json:"error"`
+}
+
+type ZksliteToken struct {
+	Id       int64  `json:"id"`
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Decimals int32  `json:"decimals"`
+}
+
+type ZksliteAccountState struct {
+	Balances   map[string]string `json:"balances"`
+	Nonce      int64             `json:"nonce"`
+	PubKeyHash string            `json:"pubKeyHash"`
+}
+
+type ZksliteAccountInfo struct {
+	Address   string              `json:"address"`
+	Id        *int64              `json:"id"`
+	Committed ZksliteAccountState `json:"committed"`
+	Verified  ZksliteAccountState `json:"verified"`
+}
+
+type ZksliteTxBlock struct {
+	BlockNumber int64 `json:"blockNumber"`
+	Committed   bool  `json:"committed"`
+	Verified    bool  `json:"verified"`
+}
+
+type ZksliteTxInfo struct {
+	Executed   bool            `json:"executed"`
+	Success    *bool           `json:"success"`
+	FailReason *string         `json:"failReason"`
+	Block      *ZksliteTxBlock `json:"block"`
+}
+
+type zksliteBlockInfo struct {
+	BlockNumber int64 `json:"blockNumber"`
+}
+
+type zksliteApiResponse struct {
+	Status string            `json:"status"`
+	Result *zksliteBlockInfo `json:"result"`
+	Error  *zksliteError     `json:"error"`
+}
+
 type ZksliteRpc struct {
 	chainInfo *loader.ChainInfo
+	tokens    map[string]*ZksliteToken
+	// tokensLoadedAt is when the token list was last requested, zero before the first load
+	tokensLoadedAt time.Time
+	tokenMutex     *sync.RWMutex
 }
 
 func NewZksliteRpc(chainInfo *loader.ChainInfo) *ZksliteRpc {
 	return &ZksliteRpc{
-		chainInfo: chainInfo,
+		chainInfo:  chainInfo,
+		tokens:     make(map[string]*ZksliteToken),
+		tokenMutex: &sync.RWMutex{},
 	}
 }
 
@@ -25,23 +98,129 @@ func (w *ZksliteRpc) Client() interface{} {
 	return w.chainInfo.Client
 }
 
+func (w *ZksliteRpc) GetChainInfo() *loader.ChainInfo {
+	return w.chainInfo
+}
+
 func (w *ZksliteRpc) Backend() int32 {
-	return 1
+	return 5
+}
+
+func (w *ZksliteRpc) IsLastCharSlash(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	return s[len(s)-1] == '/'
+}
+
+func (w *ZksliteRpc) url(path string) string {
+	var url = w.chainInfo.RpcEndPoint
+	if w.IsLastCharSlash(url) {
+		return url + path
+	}
+	return url + "/" + path
+}
+
+func (w *ZksliteRpc) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	request := zksliteRequest{
+		JsonRpc: "2.0",
+		Id:      1,
+		Method:  method,
+		Params:  params,
+	}
+	var rsp zksliteResponse
+	if err := network.RequestWithContext(ctx, w.url("jsrpc"), request, &rsp); err != nil {
+		return err
+	}
+	if rsp.Error != nil {
+		return fmt.Errorf("zkslite %s error %d: %s", method, rsp.Error.Code, rsp.Error.Message)
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+func (w *ZksliteRpc) LoadTokens(ctx context.Context) error {
+	var tokens map[string]ZksliteToken
+	if err := w.call(ctx, "tokens", &tokens); err != nil {
+		return err
+	}
+
+	addrTokens := make(map[string]*ZksliteToken, len(tokens))
+	for _, token := range tokens {
+		token := token
+		addrTokens[strings.ToLower(token.Address)] = &token
+	}
+
+	w.tokenMutex.Lock()
+	w.tokens = addrTokens
+	w.tokensLoadedAt = time.Now()
+	w.tokenMutex.Unlock()
+	return nil
+}
+
+// GetToken resolves a token by address. A miss reloads the token list at most once per
+// zksliteTokenReloadInterval, unknown addresses fail from the cache in between.
+func (w *ZksliteRpc) GetToken(ctx context.Context, tokenAddr string) (*ZksliteToken, error) {
+	tokenAddr = strings.ToLower(strings.TrimSpace(tokenAddr))
+	if util.IsHexStringZero(tokenAddr) {
+		tokenAddr = "0x0000000000000000000000000000000000000000"
+	}
+
+	w.tokenMutex.Lock()
+	token, ok := w.tokens[tokenAddr]
+	reload := !ok && time.Since(w.tokensLoadedAt) >= zksliteTokenReloadInterval
+	if reload {
+		// claim the reload so concurrent misses do not all download the list
+		w.tokensLoadedAt = time.Now()
+	}
+	w.tokenMutex.Unlock()
+	if ok {
+		return token, nil
+	}
+	if !reload {
+		return nil, fmt.Errorf("zkslite unsupport token: %s", tokenAddr)
+	}
+
+	if err := w.LoadTokens(ctx); err != nil {
+		return nil, err
+	}
+
+	w.tokenMutex.RLock()
+	token, ok = w.tokens[tokenAddr]
+	w.tokenMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("zkslite unsupport token: %s", tokenAddr)
+	}
+	return token, nil
 }
 
 func (w *ZksliteRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (loader.TokenInfo, error) {
-	return loader.TokenInfo{}, fmt.Errorf("no impl")
+	token, err := w.GetToken(ctx, tokenAddr)
+	if err != nil {
+		return loader.TokenInfo{}, err
+	}
+	return loader.TokenInfo{
+		TokenName:    token.Symbol,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: strings.TrimSpace(tokenAddr),
+		Decimals:     token.Decimals,
+		FullName:     token.Symbol,
+		TotalSupply:  big.NewInt(0),
+	}, nil
 }
 
 func (w *ZksliteRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
 	return big.NewInt(0), fmt.Errorf("not impl")
 }
 
-func (w *ZksliteRpc) IsLastCharSlash(s string) bool {
-	if len(s) == 0 {
-		return false
+func (w *ZksliteRpc) GetAccountInfo(ctx context.Context, ownerAddr string) (*ZksliteAccountInfo, error) {
+	var info ZksliteAccountInfo
+	if err := w.call(ctx, "account_info", &info, strings.TrimSpace(ownerAddr)); err != nil {
+		return nil, err
 	}
-	return s[len(s)-1] == '/'
+	return &info, nil
 }
 
 func (w *ZksliteRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
@@ -49,68 +228,90 @@ func (w *ZksliteRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr stri
 }
 
 func (w *ZksliteRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
-	ownerAddr = strings.TrimSpace(ownerAddr)
-	tokenAddr = strings.TrimSpace(tokenAddr)
-
-	var data map[string]interface{}
-	request := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "account_info",
-		"params":  []string{ownerAddr},
+	token, err := w.GetToken(ctx, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	info, err := w.GetAccountInfo(ctx, ownerAddr)
+	if err != nil {
+		return nil, err
 	}
 
-	var url = w.chainInfo.RpcEndPoint
-	if w.IsLastCharSlash(url) {
-		url += "jsrpc"
-	} else {
-		url += "/jsrpc"
+	balance, ok := info.Committed.Balances[token.Symbol]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	value, ok := new(big.Int).SetString(balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %s: %s", token.Symbol, balance)
 	}
+	return value, nil
+}
 
-	if util.IsHexStringZero(tokenAddr) || tokenAddr == "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" || tokenAddr == "0xdAC17F958D2ee523a2206206994597C13D831ec7" {
-		err := network.Request(url, request, &data)
-		if err != nil {
-			return big.NewInt(0), err
-		}
+func (w *ZksliteRpc) GetTxInfo(ctx context.Context, hash string) (*ZksliteTxInfo, error) {
+	hash = strings.TrimSpace(hash)
+	if strings.HasPrefix(hash, "0x") || strings.HasPrefix(hash, "0X") {
+		hash = "sync-tx:" + hash[2:]
+	} else if !strings.HasPrefix(hash, "sync-tx:") {
+		hash = "sync-tx:" + hash
+	}
 
-		if result, ok := data["result"].(map[string]interface{}); ok {
-			if committed, ok := result["committed"].(map[string]interface{}); ok {
-				if balances, ok := committed["balances"].(map[string]interface{}); ok {
-					if util.IsHexStringZero(tokenAddr) {
-						if eth, ok := balances["ETH"].(string); ok {
-							value, ok := big.NewInt(0).SetString(eth, 10)
-							if ok {
-								return value, nil
-							}
-						}
-					} else if tokenAddr == "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" {
-						if usdc, ok := balances["USDC"].(string); ok {
-							value, ok := big.NewInt(0).SetString(usdc, 10)
-							if ok {
-								return value, nil
-							}
-						}
-					} else if tokenAddr == "0xdAC17F958D2ee523a2206206994597C13D831ec7" {
-						if usdt, ok := balances["USDT"].(string); ok {
-							value, ok := big.NewInt(0).SetString(usdt, 10)
-							if ok {
-								return value, nil
-							}
-						}
-					}
-				}
-			}
-		}
-		return big.NewInt(0), nil
-	} else {
-		return big.NewInt(0), fmt.Errorf("not impl")
+	var info ZksliteTxInfo
+	if err := w.call(ctx, "tx_info", &info, hash); err != nil {
+		return nil, err
 	}
+	return &info, nil
 }
 
 func (w *ZksliteRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	return false, 0, fmt.Errorf("not impl")
+	return IsTxSuccessFromStatus(w.GetTxStatus(ctx, hash))
+}
+
+// GetTxStatus reports unknown txs as pending, tx_info does not tell them from queued ones
+func (w *ZksliteRpc) GetTxStatus(ctx context.Context, hash string) (*TxStatus, error) {
+	info, err := w.GetTxInfo(ctx, hash)
+	if err != nil {
+		if isNotFoundError(err) {
+			return &TxStatus{Hash: hash, State: TxStateNotFound}, nil
+		}
+		return nil, err
+	}
+	status := &TxStatus{Hash: hash, State: TxStatePending}
+	if !info.Executed || info.Success == nil {
+		return status, nil
+	}
+	if info.FailReason != nil {
+		status.RevertReason = *info.FailReason
+	}
+	if info.Block == nil {
+		// failed txs are rejected by the operator and never make it into a block
+		if !*info.Success {
+			status.State = TxStateDropped
+		}
+		return status, nil
+	}
+
+	status.State = TxStateSuccess
+	if !*info.Success {
+		status.State = TxStateReverted
+	}
+	status.BlockNumber = info.Block.BlockNumber
+	status.Finalized = &info.Block.Verified
+	return status, nil
 }
 
 func (w *ZksliteRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
-	return 0, fmt.Errorf("not impl")
+	var rsp zksliteApiResponse
+	err := network.RequestWithContext(ctx, w.url("api/v0.2/blocks/lastCommitted"), nil, &rsp)
+	if err == nil && rsp.Error != nil {
+		err = fmt.Errorf("zkslite get block error %d: %s", rsp.Error.Code, rsp.Error.Message)
+	}
+	if err == nil && rsp.Result == nil {
+		err = fmt.Errorf("zkslite get block empty result")
+	}
+	if err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return rsp.Result.BlockNumber, nil
 }
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZksliteRpc(t *testing.T) {
+	tokenLoads := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jsrpc", func(w http.ResponseWriter, r *http.Request) {
+		var req zksliteRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch req.Method {
+		case "tokens":
+			tokenLoads++
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"ETH":{"id":0,"address":"0x0000000000000000000000000000000000000000","symbol":"ETH","decimals":18},"USDC":{"id":2,"address":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","symbol":"USDC","decimals":6}}}`))
+		case "tx_info":
+			switch req.Params[0] {
+			case "sync-tx:aa":
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"executed":true,"success":true,"failReason":null,"block":{"blockNumber":120,"committed":true,"verified":false}}}`))
+			case "sync-tx:bb":
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"executed":true,"success":false,"failReason":"Not enough balance","block":null}}`))
+			case "sync-tx:cc":
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"executed":false,"success":null,"failReason":null,"block":null}}`))
+			default:
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"tx not found"}}`))
+			}
+		}
+	})
+	mux.HandleFunc("/api/v0.2/blocks/lastCommitted", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","result":{"blockNumber":121}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	zkRpc := NewZksliteRpc(&loader.ChainInfo{Name: "ZksliteMainnet", RpcEndPoint: server.URL + "/"})
+	ctx := context.TODO()
+
+	blockNumber, err := zkRpc.GetLatestBlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(121), blockNumber)
+
+	assert.NoError(t, zkRpc.LoadTokens(ctx))
+	assert.Equal(t, 1, tokenLoads)
+	token, err := zkRpc.GetToken(ctx, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	assert.NoError(t, err)
+	assert.Equal(t, "USDC", token.Symbol)
+	assert.Equal(t, int32(6), token.Decimals)
+	token, err = zkRpc.GetToken(ctx, "0x0")
+	assert.NoError(t, err)
+	assert.Equal(t, "ETH", token.Symbol)
+
+	// misses are served from the cache until the reload interval elapsed
+	_, err = zkRpc.GetToken(ctx, "0x00000000000000000000000000000000000000ff")
+	assert.ErrorContains(t, err, "unsupport token")
+	_, err = zkRpc.GetToken(ctx, "0x00000000000000000000000000000000000000ff")
+	assert.ErrorContains(t, err, "unsupport token")
+	assert.Equal(t, 1, tokenLoads)
+
+	zkRpc.tokensLoadedAt = zkRpc.tokensLoadedAt.Add(-zksliteTokenReloadInterval)
+	_, err = zkRpc.GetToken(ctx, "0x00000000000000000000000000000000000000ff")
+	assert.ErrorContains(t, err, "unsupport token")
+	assert.Equal(t, 2, tokenLoads)
+
+	status, err := zkRpc.GetTxStatus(ctx, "0xaa")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateSuccess, status.State)
+	assert.Equal(t, int64(120), status.BlockNumber)
+	assert.False(t, *status.Finalized)
+
+	status, err = zkRpc.GetTxStatus(ctx, "bb")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateDropped, status.State)
+	assert.Equal(t, "Not enough balance", status.RevertReason)
+
+	status, err = zkRpc.GetTxStatus(ctx, "sync-tx:cc")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStatePending, status.State)
+
+	status, err = zkRpc.GetTxStatus(ctx, "0xdd")
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateNotFound, status.State)
+
+	success, blockNumber, err := zkRpc.IsTxSuccess(ctx, "0xaa")
+	assert.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, int64(120), blockNumber)
+}
@@ -71,3 +71,9 @@ func SetGaugeWithLabels(keys []string, val float32, labels []metrics.Label) {
 func MeasureSince(start time.Time, keys ...string) {
 	metrics.MeasureSinceWithLabels(keys, start.UTC(), globalLabels)
 }
+
+// MeasureSinceWithLabels provides a wrapper functionality for emitting a time
+// measure metric with global labels (if any) along with the provided labels.
+func MeasureSinceWithLabels(keys []string, start time.Time, labels []metrics.Label) {
+	metrics.MeasureSinceWithLabels(keys, start.UTC(), append(labels, globalLabels...))
+}
@@ -0,0 +1,138 @@
+package bfc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+// DefaultGasBudget is the gas budget in MIST of a coin transfer when none is given
+const DefaultGasBudget = 10_000_000
+
+var ErrInsufficientCoins = errors.New("insufficient coins")
+
+// BfcBody holds the params of the unsafe_payBfc json rpc, tx_type PayBfc, or of unsafe_pay,
+// tx_type Pay, which build the transaction bytes to sign. PayBfc pays the gas from its merged
+// input coins, Pay from the Gas coin.
+type BfcBody struct {
+	TxType     string   `json:"tx_type"`
+	Signer     string   `json:"signer"`
+	InputCoins []string `json:"input_coins"`
+	Recipients []string `json:"recipients"`
+	Amounts    []string `json:"amounts"`
+	Gas        string   `json:"gas,omitempty"`
+	GasBudget  string   `json:"gas_budget"`
+}
+
+// SelectCoins picks coins largest first until they sum to amount. It returns the total of
+// the selected coins.
+func SelectCoins(coins []rpc.BfcCoin, amount *big.Int) ([]rpc.BfcCoin, *big.Int, error) {
+	balances := make(map[string]*big.Int, len(coins))
+	candidates := make([]rpc.BfcCoin, 0, len(coins))
+	for _, coin := range coins {
+		balance, ok := new(big.Int).SetString(coin.Balance, 10)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid balance %s of coin %s", coin.Balance, coin.CoinObjectId)
+		}
+		if balance.Sign() > 0 {
+			balances[coin.CoinObjectId] = balance
+			candidates = append(candidates, coin)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return balances[candidates[i].CoinObjectId].Cmp(balances[candidates[j].CoinObjectId]) > 0
+	})
+
+	total := new(big.Int)
+	for i, coin := range candidates {
+		total.Add(total, balances[coin.CoinObjectId])
+		if total.Cmp(amount) >= 0 {
+			return candidates[:i+1], total, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: have %v, need %v", ErrInsufficientCoins, total, amount)
+}
+
+func coinIds(coins []rpc.BfcCoin) []string {
+	ids := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		ids = append(ids, coin.CoinObjectId)
+	}
+	return ids
+}
+
+// TransferBodyWithCoins sends amount of the coin type from the coins of the sender. For the
+// gas coin the selected coins also cover the gas budget, for other coin types the largest
+// gas coin not below the budget pays the gas.
+func TransferBodyWithCoins(senderAddr string, receiverAddr string, coinType string, amount *big.Int, gasBudget uint64, coins []rpc.BfcCoin, gasCoins []rpc.BfcCoin) ([]byte, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %v", amount)
+	}
+	if gasBudget == 0 {
+		gasBudget = DefaultGasBudget
+	}
+	sender, err := rpc.BfcHexAddress(senderAddr)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := rpc.BfcHexAddress(receiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	budget := new(big.Int).SetUint64(gasBudget)
+	body := BfcBody{
+		Signer:     sender,
+		Recipients: []string{receiver},
+		Amounts:    []string{amount.String()},
+		GasBudget:  budget.String(),
+	}
+
+	if rpc.BfcCoinTypeOf(coinType) == rpc.BfcCoinType {
+		selected, _, err := SelectCoins(gasCoins, new(big.Int).Add(amount, budget))
+		if err != nil {
+			return nil, err
+		}
+		body.TxType = "PayBfc"
+		body.InputCoins = coinIds(selected)
+		return json.Marshal(body)
+	}
+
+	selected, _, err := SelectCoins(coins, amount)
+	if err != nil {
+		return nil, err
+	}
+	gas, _, err := SelectCoins(gasCoins, budget)
+	if err != nil {
+		return nil, fmt.Errorf("gas coin: %w", err)
+	}
+	if len(gas) > 1 {
+		return nil, fmt.Errorf("gas coin: %w: no single coin covers the budget %v", ErrInsufficientCoins, budget)
+	}
+	body.TxType = "Pay"
+	body.InputCoins = coinIds(selected)
+	body.Gas = gas[0].CoinObjectId
+	return json.Marshal(body)
+}
+
+// TransferBody lists the coins of the sender, see TransferBodyWithCoins
+func TransferBody(ctx context.Context, bfcRpc *rpc.BfcRpc, senderAddr string, receiverAddr string, coinType string, amount *big.Int, gasBudget uint64) ([]byte, error) {
+	senderAddr = strings.TrimSpace(senderAddr)
+	gasCoins, err := bfcRpc.GetCoins(ctx, senderAddr, rpc.BfcCoinType)
+	if err != nil {
+		return nil, err
+	}
+	var coins []rpc.BfcCoin
+	if rpc.BfcCoinTypeOf(coinType) != rpc.BfcCoinType {
+		coins, err = bfcRpc.GetCoins(ctx, senderAddr, coinType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return TransferBodyWithCoins(senderAddr, receiverAddr, coinType, amount, gasBudget, coins, gasCoins)
+}
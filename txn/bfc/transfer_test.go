@@ -0,0 +1,70 @@
+package bfc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSender   = "0x7a9d2f3b64c1f9a5f0b9d7e1e7ad5e3c8d12b7f3c59b8a3c6e2d1f0a9b8c7d6e"
+	testReceiver = "0x1111111111111111111111111111111111111111111111111111111111111111"
+	testBusd     = "0xabc::busd::BUSD"
+)
+
+var testGasCoins = []rpc.BfcCoin{
+	{CoinObjectId: "0xa", Balance: "5000000"},
+	{CoinObjectId: "0xb", Balance: "30000000"},
+	{CoinObjectId: "0xc", Balance: "0"},
+	{CoinObjectId: "0xd", Balance: "8000000"},
+}
+
+func decodeBody(t *testing.T, data []byte) BfcBody {
+	var body BfcBody
+	require.NoError(t, json.Unmarshal(data, &body))
+	return body
+}
+
+func TestSelectCoins(t *testing.T) {
+	selected, total, err := SelectCoins(testGasCoins, big.NewInt(35_000_000))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xb", "0xd"}, coinIds(selected))
+	assert.Equal(t, big.NewInt(38_000_000), total)
+
+	_, _, err = SelectCoins(testGasCoins, big.NewInt(50_000_000))
+	assert.ErrorIs(t, err, ErrInsufficientCoins)
+}
+
+func TestTransferBodyWithCoins(t *testing.T) {
+	data, err := TransferBodyWithCoins(testSender, testReceiver, "0x0", big.NewInt(25_000_000), 0, nil, testGasCoins)
+	require.NoError(t, err)
+	body := decodeBody(t, data)
+	assert.Equal(t, "PayBfc", body.TxType)
+	assert.Equal(t, []string{"0xb", "0xd"}, body.InputCoins)
+	assert.Equal(t, []string{"25000000"}, body.Amounts)
+	assert.Equal(t, "10000000", body.GasBudget)
+	assert.Empty(t, body.Gas)
+
+	tokenCoins := []rpc.BfcCoin{{CoinObjectId: "0xe", Balance: "700"}, {CoinObjectId: "0xf", Balance: "400"}}
+	data, err = TransferBodyWithCoins("BFC"+testSender[2:]+"363d", testReceiver, testBusd, big.NewInt(1000), 20_000_000, tokenCoins, testGasCoins)
+	require.NoError(t, err)
+	body = decodeBody(t, data)
+	assert.Equal(t, "Pay", body.TxType)
+	assert.Equal(t, testSender, body.Signer)
+	assert.Equal(t, []string{"0xe", "0xf"}, body.InputCoins)
+	assert.Equal(t, "0xb", body.Gas)
+
+	_, err = TransferBodyWithCoins(testSender, testReceiver, testBusd, big.NewInt(1000), 31_000_000, tokenCoins, testGasCoins)
+	assert.ErrorIs(t, err, ErrInsufficientCoins)
+
+	_, err = TransferBodyWithCoins(testSender, testReceiver, testBusd, big.NewInt(2000), 0, tokenCoins, testGasCoins)
+	assert.ErrorIs(t, err, ErrInsufficientCoins)
+
+	// a mistyped receiver fails the checksum instead of sending to another account
+	_, err = TransferBodyWithCoins(testSender, "BFC"+testSender[2:]+"363e", testBusd, big.NewInt(1000), 0, tokenCoins, testGasCoins)
+	assert.ErrorContains(t, err, "bad checksum")
+}
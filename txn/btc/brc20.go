@@ -1,11 +1,22 @@
 package btc
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"strings"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/owlto-dao/utils-go/address"
 	"github.com/owlto-dao/utils-go/convert"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
 )
 
 func BRC20TransferBody(receiverAddr string, tokenName string, amount *big.Int) ([]byte, error) {
@@ -23,3 +34,266 @@ func BRC20TransferBody(receiverAddr string, tokenName string, amount *big.Int) (
 	}
 	return json.Marshal(m)
 }
+
+// DefaultPostage is the value of the output holding an inscription
+const DefaultPostage = 546
+
+// MaxInscriptionPostage is ord's default postage, utxos worth no more may hold an inscription
+const MaxInscriptionPostage = 10_000
+
+// FormatBrc20Amount renders amount, in units of 10^-decimals, as the decimal string brc20
+// expects, without trailing zeros
+func FormatBrc20Amount(amount *big.Int, decimals int32) string {
+	digits := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if len(digits) <= int(decimals) {
+			digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+		}
+		point := len(digits) - int(decimals)
+		fraction := strings.TrimRight(digits[point:], "0")
+		digits = digits[:point]
+		if fraction != "" {
+			digits += "." + fraction
+		}
+	}
+	if amount.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+// Brc20TransferInscription is the content of a brc20 transfer inscription
+func Brc20TransferInscription(tokenName string, amount *big.Int, decimals int32) (string, error) {
+	tokenName = strings.TrimSpace(tokenName)
+	if n := len(tokenName); n != 4 && n != 5 {
+		return "", fmt.Errorf("invalid brc20 ticker %s", tokenName)
+	}
+	if amount.Sign() <= 0 {
+		return "", fmt.Errorf("invalid brc20 amount %v", amount)
+	}
+	if decimals < 0 || decimals > 18 {
+		return "", fmt.Errorf("invalid brc20 decimals %d", decimals)
+	}
+	content, err := json.Marshal(struct {
+		P    string `json:"p"`
+		Op   string `json:"op"`
+		Tick string `json:"tick"`
+		Amt  string `json:"amt"`
+	}{"brc-20", "transfer", tokenName, FormatBrc20Amount(amount, decimals)})
+	return string(content), err
+}
+
+// inscriptionScript is the ord envelope of a text inscription behind a checksig of the key
+func inscriptionScript(key *btcec.PublicKey, content []byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(key)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("ord")).
+		// the content type tag 1 as a one byte push, AddData would turn it into OP_1
+		AddOp(txscript.OP_DATA_1).AddOp(txscript.OP_DATA_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_0)
+	for len(content) > 0 {
+		chunk := content
+		if len(chunk) > txscript.MaxScriptElementSize {
+			chunk = chunk[:txscript.MaxScriptElementSize]
+		}
+		builder.AddFullData(chunk)
+		content = content[len(chunk):]
+	}
+	return builder.AddOp(txscript.OP_ENDIF).Script()
+}
+
+type Brc20TransferRequest struct {
+	// Network is one of address.BitcoinMainnet and friends, empty is mainnet
+	Network    string
+	SenderAddr string
+	// SenderPubKey is the hex public key of the sender, it signs the reveal through the
+	// inscription script and can spend the commit output by key path
+	SenderPubKey string
+	ReceiverAddr string
+	TokenName    string
+	Amount       *big.Int
+	Decimals     int32
+	// FeeRate is in sat/vB
+	FeeRate float64
+	// Utxos pay the commit and the transfer and must be cardinal, without inscriptions nor
+	// runes. Utxos of MaxInscriptionPostage sats or less are never spent, they may hold an
+	// inscription such as an earlier brc20 transfer.
+	Utxos    []rpc.BitcoinUtxo
+	Strategy SelectStrategy
+	// Postage defaults to DefaultPostage
+	Postage            int64
+	IncludeUnconfirmed bool
+}
+
+// Brc20TransferResult holds the three txs to sign and broadcast in order: the commit funds
+// the inscription address, the reveal inscribes the transfer to the sender, and the transfer
+// sends the inscribed sat to the receiver. Each tx spends outputs of the previous ones.
+type Brc20TransferResult struct {
+	Inscription string
+	CommitAddr  string
+	Commit      *PsbtResult
+	Reveal      *PsbtResult
+	Transfer    *PsbtResult
+}
+
+func BuildBrc20Transfer(req *Brc20TransferRequest) (*Brc20TransferResult, error) {
+	params, err := address.GetBitcoinParams(req.Network)
+	if err != nil {
+		return nil, err
+	}
+	inscription, err := Brc20TransferInscription(req.TokenName, req.Amount, req.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	pubKeyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(req.SenderPubKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender public key: %w", err)
+	}
+	var pubKey *btcec.PublicKey
+	if len(pubKeyBytes) == 32 {
+		pubKey, err = schnorr.ParsePubKey(pubKeyBytes)
+	} else {
+		pubKey, err = btcec.ParsePubKey(pubKeyBytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender public key: %w", err)
+	}
+	postage := req.Postage
+	if postage <= 0 {
+		postage = DefaultPostage
+	}
+	sender, err := address.Parse(loader.BitcoinBackend, req.Network, req.SenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	inWeight, err := inputWeight(sender.Type)
+	if err != nil {
+		return nil, err
+	}
+	senderScript, err := payToAddrScript(req.SenderAddr, params)
+	if err != nil {
+		return nil, err
+	}
+	receiverScript, err := payToAddrScript(req.ReceiverAddr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := inscriptionScript(pubKey, []byte(inscription))
+	if err != nil {
+		return nil, err
+	}
+	tree := txscript.AssembleTaprootScriptTree(txscript.NewBaseTapLeaf(script))
+	proof := tree.LeafMerkleProofs[0].ToControlBlock(pubKey)
+	controlBlock, err := proof.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	rootHash := tree.RootNode.TapHash()
+	commitAddr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootOutputKey(pubKey, rootHash[:])), params)
+	if err != nil {
+		return nil, err
+	}
+	commitScript, err := txscript.PayToAddrScript(commitAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]rpc.BitcoinUtxo, 0, len(req.Utxos))
+	for _, utxo := range req.Utxos {
+		if utxo.Value > MaxInscriptionPostage {
+			utxos = append(utxos, utxo)
+		}
+	}
+
+	// the reveal spends the commit output by script path to the sender
+	revealInputWeight := int64(4*41 + 1 + 1 + 64 + wire.VarIntSerializeSize(uint64(len(script))) + len(script) + 1 + len(controlBlock))
+	revealWeight := txOverheadWeight + revealInputWeight + outputWeight(senderScript)
+	revealFee := feeOf(revealWeight, req.FeeRate)
+
+	commit, err := BuildPsbt(&PsbtRequest{
+		Network:            req.Network,
+		SenderAddr:         req.SenderAddr,
+		ReceiverAddr:       commitAddr.EncodeAddress(),
+		Amount:             postage + revealFee,
+		FeeRate:            req.FeeRate,
+		Utxos:              utxos,
+		Strategy:           req.Strategy,
+		IncludeUnconfirmed: req.IncludeUnconfirmed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build commit error %w", err)
+	}
+	commitHash := commit.Packet.UnsignedTx.TxHash()
+
+	revealInput := rpc.BitcoinUtxo{TxId: commitHash.String(), Vout: 0, Value: postage + revealFee, ScriptPubKey: hex.EncodeToString(commitScript)}
+	revealPacket, err := newPsbt([]rpc.BitcoinUtxo{revealInput}, []*wire.TxOut{wire.NewTxOut(postage, senderScript)})
+	if err != nil {
+		return nil, err
+	}
+	revealPacket.Inputs[0].TaprootInternalKey = schnorr.SerializePubKey(pubKey)
+	revealPacket.Inputs[0].TaprootLeafScript = []*psbt.TaprootTapLeafScript{{
+		ControlBlock: controlBlock,
+		Script:       script,
+		LeafVersion:  txscript.BaseLeafVersion,
+	}}
+	reveal, err := encodePsbt(revealPacket, []rpc.BitcoinUtxo{revealInput}, revealFee, 0, revealWeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// the transfer moves the inscribed first input to the first output, paying the fee from
+	// the utxos left by the commit, its change included
+	revealHash := revealPacket.UnsignedTx.TxHash()
+	inscribed := rpc.BitcoinUtxo{TxId: revealHash.String(), Vout: 0, Value: postage, ScriptPubKey: hex.EncodeToString(senderScript)}
+	spent := make(map[string]bool, len(commit.Inputs))
+	for _, utxo := range commit.Inputs {
+		spent[fmt.Sprintf("%s:%d", utxo.TxId, utxo.Vout)] = true
+	}
+	funding := make([]rpc.BitcoinUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !spent[fmt.Sprintf("%s:%d", utxo.TxId, utxo.Vout)] && (utxo.Confirmed || req.IncludeUnconfirmed) {
+			funding = append(funding, utxo)
+		}
+	}
+	if commit.Change > 0 {
+		changeIndex := len(commit.Packet.UnsignedTx.TxOut) - 1
+		funding = append(funding, rpc.BitcoinUtxo{TxId: commitHash.String(), Vout: uint32(changeIndex), Value: commit.Change, ScriptPubKey: hex.EncodeToString(senderScript)})
+	}
+	selector := &coinSelector{
+		feeRate:      req.FeeRate,
+		baseWeight:   txOverheadWeight + inWeight + outputWeight(receiverScript),
+		inputWeight:  inWeight,
+		changeWeight: outputWeight(senderScript),
+		dust:         DustLimit(sender.Type),
+	}
+	selection, err := selector.selectCoins(req.Strategy, funding)
+	if err != nil {
+		return nil, fmt.Errorf("build transfer error %w", err)
+	}
+	inputs := append([]rpc.BitcoinUtxo{inscribed}, selection.Inputs...)
+	outputs := []*wire.TxOut{wire.NewTxOut(postage, receiverScript)}
+	if selection.Change > 0 {
+		outputs = append(outputs, wire.NewTxOut(selection.Change, senderScript))
+	}
+	transferPacket, err := newPsbt(inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := encodePsbt(transferPacket, inputs, selection.Fee, selection.Change, selection.Weight)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Brc20TransferResult{
+		Inscription: inscription,
+		CommitAddr:  commitAddr.EncodeAddress(),
+		Commit:      commit,
+		Reveal:      reveal,
+		Transfer:    transfer,
+	}, nil
+}
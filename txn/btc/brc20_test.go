@@ -0,0 +1,78 @@
+package btc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBrc20Amount(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1000000000000000000000000000000", 10)
+	assert.Equal(t, "1000000000000", FormatBrc20Amount(amount, 18))
+	assert.Equal(t, "1.5", FormatBrc20Amount(big.NewInt(1_500_000_000_000_000_000), 18))
+	assert.Equal(t, "0.005", FormatBrc20Amount(big.NewInt(5), 3))
+	assert.Equal(t, "1000", FormatBrc20Amount(big.NewInt(1000), 0))
+}
+
+func TestBuildBrc20Transfer(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	senderAddr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(key.PubKey())), &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+
+	result, err := BuildBrc20Transfer(&Brc20TransferRequest{
+		Network:      address.BitcoinRegtest,
+		SenderAddr:   senderAddr.EncodeAddress(),
+		SenderPubKey: hex.EncodeToString(key.PubKey().SerializeCompressed()),
+		ReceiverAddr: newP2wpkhAddress(t),
+		TokenName:    "ordi",
+		Amount:       big.NewInt(1_500_000_000_000_000_000),
+		Decimals:     18,
+		FeeRate:      3,
+		// the small utxos may carry inscriptions and must not pay fees
+		Utxos: newUtxos(100_000, DefaultPostage, MaxInscriptionPostage),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"p":"brc-20","op":"transfer","tick":"ordi","amt":"1.5"}`, result.Inscription)
+	assert.True(t, strings.HasPrefix(result.CommitAddr, "bcrt1p"))
+
+	commitTx, revealTx, transferTx := result.Commit.Packet.UnsignedTx, result.Reveal.Packet.UnsignedTx, result.Transfer.Packet.UnsignedTx
+	assert.Equal(t, commitTx.TxHash(), revealTx.TxIn[0].PreviousOutPoint.Hash)
+	assert.Equal(t, int64(DefaultPostage)+result.Reveal.Fee, commitTx.TxOut[0].Value)
+	assert.Equal(t, int64(DefaultPostage), revealTx.TxOut[0].Value)
+	// the transfer spends the inscribed output first and is funded by the commit change
+	require.Len(t, transferTx.TxIn, 2)
+	assert.Equal(t, revealTx.TxHash(), transferTx.TxIn[0].PreviousOutPoint.Hash)
+	assert.Equal(t, commitTx.TxHash(), transferTx.TxIn[1].PreviousOutPoint.Hash)
+	assert.Equal(t, int64(DefaultPostage), transferTx.TxOut[0].Value)
+	require.Len(t, commitTx.TxIn, 1)
+	assert.Equal(t, fmt.Sprintf("%064x", 1), commitTx.TxIn[0].PreviousOutPoint.Hash.String())
+
+	// sign the reveal through the inscription script and run it
+	input := result.Reveal.Packet.Inputs[0]
+	leafScript := input.TaprootLeafScript[0]
+	prevOut := input.WitnessUtxo
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHashes := txscript.NewTxSigHashes(revealTx, fetcher)
+	leaf := txscript.NewBaseTapLeaf(leafScript.Script)
+	sig, err := txscript.RawTxInTapscriptSignature(revealTx, sigHashes, 0, prevOut.Value, prevOut.PkScript, leaf, txscript.SigHashDefault, key)
+	require.NoError(t, err)
+	revealTx.TxIn[0].Witness = wire.TxWitness{sig, leafScript.Script, leafScript.ControlBlock}
+	engine, err := txscript.NewEngine(prevOut.PkScript, revealTx, 0, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, fetcher)
+	require.NoError(t, err)
+	require.NoError(t, engine.Execute())
+	// the estimated vsize covers the signed reveal
+	assert.GreaterOrEqual(t, result.Reveal.Vsize, (int64(revealTx.SerializeSizeStripped())*3+int64(revealTx.SerializeSize())+3)/4)
+}
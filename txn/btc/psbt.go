@@ -0,0 +1,221 @@
+package btc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/convert"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+// rbfSequence signals opt-in replace by fee on every input
+const rbfSequence = wire.MaxTxInSequenceNum - 2
+
+// PsbtRequest pays Amount satoshis from the P2WPKH or P2TR SenderAddr, spending Utxos
+// usually listed by rpc.BitcoinRpc.ListUtxos
+type PsbtRequest struct {
+	// Network is one of address.BitcoinMainnet and friends, empty is mainnet
+	Network      string
+	SenderAddr   string
+	ReceiverAddr string
+	Amount       int64
+	// FeeRate is in sat/vB
+	FeeRate  float64
+	Utxos    []rpc.BitcoinUtxo
+	Strategy SelectStrategy
+	// ChangeAddr defaults to SenderAddr
+	ChangeAddr string
+	// TargetAddr, when set, is carried in an OP_RETURN output for the owlto maker
+	TargetAddr string
+	// IncludeUnconfirmed allows spending utxos not mined yet
+	IncludeUnconfirmed bool
+}
+
+type PsbtResult struct {
+	// Psbt is the base64 unsigned psbt, the inputs carry their witness utxo for signing
+	Psbt   string
+	Packet *psbt.Packet
+	Inputs []rpc.BitcoinUtxo
+	Fee    int64
+	Change int64
+	Vsize  int64
+}
+
+func BuildPsbt(req *PsbtRequest) (*PsbtResult, error) {
+	params, err := address.GetBitcoinParams(req.Network)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("invalid amount %d", req.Amount)
+	}
+	if req.FeeRate <= 0 {
+		return nil, fmt.Errorf("invalid fee rate %v", req.FeeRate)
+	}
+
+	sender, err := address.Parse(loader.BitcoinBackend, req.Network, req.SenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	inWeight, err := inputWeight(sender.Type)
+	if err != nil {
+		return nil, err
+	}
+	senderScript, err := payToAddrScript(req.SenderAddr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := address.Parse(loader.BitcoinBackend, req.Network, req.ReceiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount < DustLimit(receiver.Type) {
+		return nil, fmt.Errorf("amount %d below dust limit %d", req.Amount, DustLimit(receiver.Type))
+	}
+	receiverScript, err := payToAddrScript(req.ReceiverAddr, params)
+	if err != nil {
+		return nil, err
+	}
+	outputs := []*wire.TxOut{wire.NewTxOut(req.Amount, receiverScript)}
+
+	if target := strings.TrimSpace(req.TargetAddr); target != "" {
+		if len(target) > txscript.MaxDataCarrierSize {
+			return nil, fmt.Errorf("target address longer than %d bytes", txscript.MaxDataCarrierSize)
+		}
+		script, err := txscript.NullDataScript([]byte(target))
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, wire.NewTxOut(0, script))
+	}
+
+	changeAddr := strings.TrimSpace(req.ChangeAddr)
+	if changeAddr == "" {
+		changeAddr = req.SenderAddr
+	}
+	change, err := address.Parse(loader.BitcoinBackend, req.Network, changeAddr)
+	if err != nil {
+		return nil, err
+	}
+	changeScript, err := payToAddrScript(changeAddr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	baseWeight := int64(txOverheadWeight)
+	for _, output := range outputs {
+		baseWeight += outputWeight(output.PkScript)
+	}
+	selector := &coinSelector{
+		amount:       req.Amount,
+		feeRate:      req.FeeRate,
+		baseWeight:   baseWeight,
+		inputWeight:  inWeight,
+		changeWeight: outputWeight(changeScript),
+		dust:         DustLimit(change.Type),
+	}
+	utxos := make([]rpc.BitcoinUtxo, 0, len(req.Utxos))
+	for _, utxo := range req.Utxos {
+		if utxo.Confirmed || req.IncludeUnconfirmed {
+			utxos = append(utxos, utxo)
+		}
+	}
+	selection, err := selector.selectCoins(req.Strategy, utxos)
+	if err != nil {
+		return nil, err
+	}
+	if selection.Change > 0 {
+		outputs = append(outputs, wire.NewTxOut(selection.Change, changeScript))
+	}
+
+	inputs := make([]rpc.BitcoinUtxo, 0, len(selection.Inputs))
+	for _, utxo := range selection.Inputs {
+		if utxo.ScriptPubKey == "" {
+			utxo.ScriptPubKey = hex.EncodeToString(senderScript)
+		}
+		inputs = append(inputs, utxo)
+	}
+	packet, err := newPsbt(inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return encodePsbt(packet, inputs, selection.Fee, selection.Change, selection.Weight)
+}
+
+// newPsbt spends the utxos, which must have their script, with replace by fee signaled
+func newPsbt(inputs []rpc.BitcoinUtxo, outputs []*wire.TxOut) (*psbt.Packet, error) {
+	outpoints := make([]*wire.OutPoint, 0, len(inputs))
+	sequences := make([]uint32, 0, len(inputs))
+	for _, utxo := range inputs {
+		hash, err := chainhash.NewHashFromStr(utxo.TxId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo txid %s: %w", utxo.TxId, err)
+		}
+		outpoints = append(outpoints, wire.NewOutPoint(hash, utxo.Vout))
+		sequences = append(sequences, rbfSequence)
+	}
+	packet, err := psbt.New(outpoints, outputs, 2, 0, sequences)
+	if err != nil {
+		return nil, err
+	}
+	for i, utxo := range inputs {
+		script, err := hex.DecodeString(utxo.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo script %s: %w", utxo.ScriptPubKey, err)
+		}
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(utxo.Value, script)
+	}
+	return packet, nil
+}
+
+func encodePsbt(packet *psbt.Packet, inputs []rpc.BitcoinUtxo, fee int64, change int64, weight int64) (*PsbtResult, error) {
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return nil, err
+	}
+	return &PsbtResult{
+		Psbt:   encoded,
+		Packet: packet,
+		Inputs: inputs,
+		Fee:    fee,
+		Change: change,
+		Vsize:  (weight + 3) / 4,
+	}, nil
+}
+
+// PsbtTransferBody wraps the psbt in the tx_type envelope of TransferBody
+func PsbtTransferBody(req *PsbtRequest) ([]byte, error) {
+	result, err := BuildPsbt(req)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"psbt":   result.Psbt,
+		"fee":    result.Fee,
+		"change": result.Change,
+	}
+	m := map[string]interface{}{
+		"tx_type": "Psbt",
+		"data":    convert.ConvertToJsonString(data),
+	}
+	return json.Marshal(m)
+}
+
+func payToAddrScript(addr string, params *chaincfg.Params) ([]byte, error) {
+	decoded, err := btcutil.DecodeAddress(strings.TrimSpace(addr), params)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(decoded)
+}
@@ -0,0 +1,95 @@
+package btc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newP2wpkhAddress(t *testing.T) string {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(key.PubKey().SerializeCompressed()), &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	return addr.EncodeAddress()
+}
+
+func newUtxos(values ...int64) []rpc.BitcoinUtxo {
+	utxos := make([]rpc.BitcoinUtxo, 0, len(values))
+	for i, value := range values {
+		utxos = append(utxos, rpc.BitcoinUtxo{TxId: fmt.Sprintf("%064x", i+1), Vout: uint32(i), Value: value, Confirmed: true})
+	}
+	return utxos
+}
+
+func TestBuildPsbt(t *testing.T) {
+	sender, receiver := newP2wpkhAddress(t), newP2wpkhAddress(t)
+	req := &PsbtRequest{
+		Network:      address.BitcoinRegtest,
+		SenderAddr:   sender,
+		ReceiverAddr: receiver,
+		Amount:       50_000,
+		FeeRate:      2,
+		Utxos:        append(newUtxos(100_000, 30_000, 20_000, 200), rpc.BitcoinUtxo{TxId: fmt.Sprintf("%064x", 9), Value: 1_000_000}),
+		TargetAddr:   "0x5e809A85Aa182A9921EDD10a4163745bb3e36284",
+	}
+
+	result, err := BuildPsbt(req)
+	require.NoError(t, err)
+	// the unconfirmed utxo is skipped, the largest confirmed one pays with change
+	require.Len(t, result.Inputs, 1)
+	assert.Equal(t, int64(100_000), result.Inputs[0].Value)
+	assert.Equal(t, int64(100_000-50_000)-result.Fee, result.Change)
+	// overhead 10.5, one p2wpkh input 68.5, two p2wpkh outputs 31 each and the OP_RETURN 53
+	assert.Equal(t, int64(194), result.Vsize)
+	assert.Equal(t, int64(388), result.Fee)
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader([]byte(result.Psbt)), true)
+	require.NoError(t, err)
+	tx := packet.UnsignedTx
+	require.Len(t, tx.TxOut, 3)
+	assert.Equal(t, int64(50_000), tx.TxOut[0].Value)
+	pushes, err := txscript.PushedData(tx.TxOut[1].PkScript)
+	require.NoError(t, err)
+	assert.Equal(t, req.TargetAddr, string(pushes[0]))
+	assert.Equal(t, result.Change, tx.TxOut[2].Value)
+	assert.Equal(t, int64(100_000), packet.Inputs[0].WitnessUtxo.Value)
+	fee, err := psbt.SumUtxoInputValues(packet)
+	require.NoError(t, err)
+	assert.Equal(t, result.Fee, fee-50_000-result.Change)
+
+	// branch and bound finds the 30_000 + 20_000 pair whose excess is below the cost of change
+	req.Strategy = SelectBranchAndBound
+	req.TargetAddr = ""
+	req.Amount = 49_500
+	result, err = BuildPsbt(req)
+	require.NoError(t, err)
+	require.Len(t, result.Inputs, 2)
+	assert.Equal(t, int64(0), result.Change)
+	assert.Equal(t, int64(500), result.Fee)
+
+	// an excess below the dust limit goes to the fee
+	req.Strategy = SelectLargestFirst
+	req.Amount = 99_500
+	result, err = BuildPsbt(req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Change)
+	assert.Equal(t, int64(500), result.Fee)
+
+	req.Amount = 200_000
+	_, err = BuildPsbt(req)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	req.IncludeUnconfirmed = true
+	_, err = BuildPsbt(req)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,197 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+type SelectStrategy string
+
+const (
+	SelectLargestFirst SelectStrategy = "largest_first"
+	// SelectBranchAndBound looks for inputs paying the amount and fee without change, and falls
+	// back to SelectLargestFirst when there is none
+	SelectBranchAndBound SelectStrategy = "branch_and_bound"
+)
+
+// bnbMaxTries bounds the branch and bound search like bitcoin core does
+const bnbMaxTries = 100000
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+var errNoExactMatch = errors.New("no changeless input set")
+
+// weights in weight units, the tx overhead includes the segwit marker and flag and a
+// signature is counted at 72 bytes
+const (
+	txOverheadWeight  = 4*(4+1+1+4) + 2
+	p2wpkhInputWeight = 4*41 + 1 + 1 + 72 + 1 + 33
+	p2trInputWeight   = 4*41 + 1 + 1 + 64
+)
+
+func inputWeight(addrType address.Type) (int64, error) {
+	switch addrType {
+	case address.TypeP2WPKH:
+		return p2wpkhInputWeight, nil
+	case address.TypeP2TR:
+		return p2trInputWeight, nil
+	}
+	return 0, fmt.Errorf("unsupported input address type %s", addrType)
+}
+
+func outputWeight(pkScript []byte) int64 {
+	return int64(4 * (8 + wire.VarIntSerializeSize(uint64(len(pkScript))) + len(pkScript)))
+}
+
+// DustLimit is the smallest output of the address type relayed at the default dust fee rate
+func DustLimit(addrType address.Type) int64 {
+	switch addrType {
+	case address.TypeP2PKH:
+		return 546
+	case address.TypeP2SH:
+		return 540
+	case address.TypeP2WPKH:
+		return 294
+	}
+	return 330
+}
+
+// feeOf rounds the weight up to virtual bytes and the fee up to satoshis
+func feeOf(weight int64, feeRate float64) int64 {
+	return int64(math.Ceil(float64((weight+3)/4) * feeRate))
+}
+
+type CoinSelection struct {
+	Inputs []rpc.BitcoinUtxo
+	Fee    int64
+	// Change is 0 when the excess is below the dust limit and left to the fee
+	Change int64
+	Weight int64
+}
+
+// coinSelector pays amount plus the fee of a tx of baseWeight, inputs of inputWeight each
+// and, when worth it, a change output of changeWeight
+type coinSelector struct {
+	amount       int64
+	feeRate      float64
+	baseWeight   int64
+	inputWeight  int64
+	changeWeight int64
+	dust         int64
+}
+
+func (s *coinSelector) selectCoins(strategy SelectStrategy, utxos []rpc.BitcoinUtxo) (*CoinSelection, error) {
+	// inputs costing more to spend than they hold are never selected
+	inputFee := feeOf(s.inputWeight, s.feeRate)
+	candidates := make([]rpc.BitcoinUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.Value > inputFee {
+			candidates = append(candidates, utxo)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Value > candidates[j].Value })
+
+	switch strategy {
+	case SelectBranchAndBound:
+		selection, err := s.branchAndBound(candidates)
+		if !errors.Is(err, errNoExactMatch) {
+			return selection, err
+		}
+		return s.largestFirst(candidates)
+	case SelectLargestFirst, "":
+		return s.largestFirst(candidates)
+	}
+	return nil, fmt.Errorf("unknown select strategy %s", strategy)
+}
+
+func (s *coinSelector) largestFirst(candidates []rpc.BitcoinUtxo) (*CoinSelection, error) {
+	var total int64
+	for i, utxo := range candidates {
+		total += utxo.Value
+		if selection := s.finish(candidates[:i+1], total); selection != nil {
+			return selection, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: have %d, need %d plus fees", ErrInsufficientFunds, total, s.amount)
+}
+
+// finish adds change when the excess pays for it and stays above dust, nil if total is short
+func (s *coinSelector) finish(inputs []rpc.BitcoinUtxo, total int64) *CoinSelection {
+	weight := s.baseWeight + int64(len(inputs))*s.inputWeight
+	fee := feeOf(weight, s.feeRate)
+	if total < s.amount+fee {
+		return nil
+	}
+	selection := &CoinSelection{Inputs: append([]rpc.BitcoinUtxo{}, inputs...), Fee: total - s.amount, Weight: weight}
+	changeFee := feeOf(weight+s.changeWeight, s.feeRate)
+	if change := total - s.amount - changeFee; change >= s.dust {
+		selection.Fee = changeFee
+		selection.Change = change
+		selection.Weight += s.changeWeight
+	}
+	return selection
+}
+
+// branchAndBound searches the input set whose effective value, the value minus the input fee,
+// exceeds the target by less than the cost of a change output, keeping the smallest excess.
+// candidates are sorted by descending value.
+func (s *coinSelector) branchAndBound(candidates []rpc.BitcoinUtxo) (*CoinSelection, error) {
+	inputFee := feeOf(s.inputWeight, s.feeRate)
+	target := s.amount + feeOf(s.baseWeight, s.feeRate)
+	costOfChange := feeOf(s.changeWeight, s.feeRate) + inputFee
+
+	effective := make([]int64, len(candidates))
+	remaining := make([]int64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		effective[i] = candidates[i].Value - inputFee
+		remaining[i] = remaining[i+1] + effective[i]
+	}
+	if remaining[0] < target {
+		return nil, errNoExactMatch
+	}
+
+	var best []int
+	bestExcess := int64(math.MaxInt64)
+	selected := make([]int, 0, len(candidates))
+	tries := 0
+	var search func(index int, value int64)
+	search = func(index int, value int64) {
+		tries++
+		if tries > bnbMaxTries || value > target+costOfChange || value+remaining[index] < target {
+			return
+		}
+		if value >= target {
+			if excess := value - target; excess < bestExcess {
+				bestExcess = excess
+				best = append(best[:0], selected...)
+			}
+			return
+		}
+		if index == len(candidates) {
+			return
+		}
+		selected = append(selected, index)
+		search(index+1, value+effective[index])
+		selected = selected[:len(selected)-1]
+		search(index+1, value)
+	}
+	search(0, 0)
+	if best == nil {
+		return nil, errNoExactMatch
+	}
+
+	selection := &CoinSelection{Weight: s.baseWeight + int64(len(best))*s.inputWeight}
+	var total int64
+	for _, index := range best {
+		selection.Inputs = append(selection.Inputs, candidates[index])
+		total += candidates[index].Value
+	}
+	selection.Fee = total - s.amount
+	return selection, nil
+}
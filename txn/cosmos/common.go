@@ -0,0 +1,85 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+const MsgSendType = "/cosmos.bank.v1beta1.MsgSend"
+
+// CosmosBody is the body of a tx, the messages are in the proto json form with their @type
+type CosmosBody struct {
+	Messages []json.RawMessage `json:"messages"`
+	Memo     string            `json:"memo"`
+}
+
+type MsgSend struct {
+	Type        string           `json:"@type"`
+	FromAddress string           `json:"from_address"`
+	ToAddress   string           `json:"to_address"`
+	Amount      []rpc.CosmosCoin `json:"amount"`
+}
+
+func ToBody(msgs []interface{}, memo string) ([]byte, error) {
+	body := CosmosBody{Messages: make([]json.RawMessage, 0, len(msgs)), Memo: memo}
+	for _, msg := range msgs {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		body.Messages = append(body.Messages, data)
+	}
+	return json.Marshal(body)
+}
+
+func NewCoin(denom string, amount *big.Int) (rpc.CosmosCoin, error) {
+	denom = strings.TrimSpace(denom)
+	if denom == "" {
+		return rpc.CosmosCoin{}, fmt.Errorf("empty denom")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return rpc.CosmosCoin{}, fmt.Errorf("invalid amount %v", amount)
+	}
+	return rpc.CosmosCoin{Denom: denom, Amount: amount.String()}, nil
+}
+
+// normalize checks the bech32 address, with any prefix, and lowercases it
+func normalize(addr string) (string, error) {
+	return address.Normalize(loader.CosmosBackend, "", addr)
+}
+
+func NewMsgSend(senderAddr string, receiverAddr string, denom string, amount *big.Int) (*MsgSend, error) {
+	sender, err := normalize(senderAddr)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := normalize(receiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	coin, err := NewCoin(denom, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &MsgSend{
+		Type:        MsgSendType,
+		FromAddress: sender,
+		ToAddress:   receiver,
+		Amount:      []rpc.CosmosCoin{coin},
+	}, nil
+}
+
+// SendBody sends amount of the bank denom, rpc.CosmosRpc.Denom resolves the gas token one
+func SendBody(senderAddr string, receiverAddr string, denom string, amount *big.Int, memo string) ([]byte, error) {
+	msg, err := NewMsgSend(senderAddr, receiverAddr, denom, amount)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody([]interface{}{msg}, memo)
+}
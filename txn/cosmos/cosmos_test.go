@@ -0,0 +1,71 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSender   = "osmo1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqmcn030"
+	testReceiver = "osmo1qyqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqzf5dfv"
+	testIbcPeer  = "cosmos1qyqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq2j8al7"
+)
+
+func TestSendBody(t *testing.T) {
+	body, err := SendBody(testSender, testReceiver, "uosmo", big.NewInt(1500), "0xabc")
+	require.NoError(t, err)
+
+	var cosmosBody CosmosBody
+	require.NoError(t, json.Unmarshal(body, &cosmosBody))
+	assert.Equal(t, "0xabc", cosmosBody.Memo)
+	require.Len(t, cosmosBody.Messages, 1)
+	var msg MsgSend
+	require.NoError(t, json.Unmarshal(cosmosBody.Messages[0], &msg))
+	assert.Equal(t, MsgSendType, msg.Type)
+	assert.Equal(t, testSender, msg.FromAddress)
+	assert.Equal(t, testReceiver, msg.ToAddress)
+	assert.Equal(t, "uosmo", msg.Amount[0].Denom)
+	assert.Equal(t, "1500", msg.Amount[0].Amount)
+
+	_, err = SendBody(testSender, "osmo1invalid", "uosmo", big.NewInt(1500), "")
+	assert.Error(t, err)
+	_, err = SendBody(testSender, testReceiver, "uosmo", big.NewInt(0), "")
+	assert.Error(t, err)
+}
+
+func TestIbcTransferBody(t *testing.T) {
+	req := &IbcTransferRequest{
+		SenderAddr:    testSender,
+		ReceiverAddr:  testIbcPeer,
+		Denom:         "uosmo",
+		Amount:        big.NewInt(1500),
+		SourceChannel: "channel-0",
+		Memo:          "0xabc",
+	}
+	body, err := IbcTransferBody(req)
+	require.NoError(t, err)
+
+	var cosmosBody CosmosBody
+	require.NoError(t, json.Unmarshal(body, &cosmosBody))
+	assert.Equal(t, "0xabc", cosmosBody.Memo)
+	var msg MsgTransfer
+	require.NoError(t, json.Unmarshal(cosmosBody.Messages[0], &msg))
+	assert.Equal(t, MsgTransferType, msg.Type)
+	assert.Equal(t, "transfer", msg.SourcePort)
+	assert.Equal(t, testIbcPeer, msg.Receiver)
+	assert.Empty(t, msg.Memo)
+
+	timeout, err := strconv.ParseInt(msg.TimeoutTimestamp, 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(DefaultIbcTimeout).UnixNano(), timeout, float64(time.Minute))
+
+	req.SourceChannel = "0"
+	_, err = IbcTransferBody(req)
+	assert.Error(t, err)
+}
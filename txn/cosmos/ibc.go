@@ -0,0 +1,94 @@
+package cosmos
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+const (
+	MsgTransferType = "/ibc.applications.transfer.v1.MsgTransfer"
+	// DefaultIbcTimeout is how long the packet may take to be received on the counterparty
+	DefaultIbcTimeout = 10 * time.Minute
+)
+
+type IbcHeight struct {
+	RevisionNumber string `json:"revision_number"`
+	RevisionHeight string `json:"revision_height"`
+}
+
+type MsgTransfer struct {
+	Type          string         `json:"@type"`
+	SourcePort    string         `json:"source_port"`
+	SourceChannel string         `json:"source_channel"`
+	Token         rpc.CosmosCoin `json:"token"`
+	Sender        string         `json:"sender"`
+	Receiver      string         `json:"receiver"`
+	TimeoutHeight IbcHeight      `json:"timeout_height"`
+	// TimeoutTimestamp is in unix nanoseconds
+	TimeoutTimestamp string `json:"timeout_timestamp"`
+	Memo             string `json:"memo,omitempty"`
+}
+
+// IbcTransferRequest moves Amount of Denom over the transfer port of SourceChannel to
+// Receiver on the counterparty chain
+type IbcTransferRequest struct {
+	SenderAddr    string
+	ReceiverAddr  string
+	Denom         string
+	Amount        *big.Int
+	SourceChannel string
+	// Timeout defaults to DefaultIbcTimeout, there is no timeout height
+	Timeout time.Duration
+	// Memo is the tx memo, PacketMemo the memo relayed with the packet, used by
+	// packet forwarding and ibc hooks
+	Memo       string
+	PacketMemo string
+}
+
+func NewMsgTransfer(req *IbcTransferRequest) (*MsgTransfer, error) {
+	sender, err := normalize(req.SenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := normalize(req.ReceiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	coin, err := NewCoin(req.Denom, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	channel := strings.TrimSpace(req.SourceChannel)
+	if !strings.HasPrefix(channel, "channel-") {
+		return nil, fmt.Errorf("invalid source channel %s", req.SourceChannel)
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = DefaultIbcTimeout
+	}
+
+	return &MsgTransfer{
+		Type:             MsgTransferType,
+		SourcePort:       "transfer",
+		SourceChannel:    channel,
+		Token:            coin,
+		Sender:           sender,
+		Receiver:         receiver,
+		TimeoutHeight:    IbcHeight{RevisionNumber: "0", RevisionHeight: "0"},
+		TimeoutTimestamp: strconv.FormatInt(time.Now().Add(timeout).UnixNano(), 10),
+		Memo:             req.PacketMemo,
+	}, nil
+}
+
+func IbcTransferBody(req *IbcTransferRequest) ([]byte, error) {
+	msg, err := NewMsgTransfer(req)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody([]interface{}{msg}, req.Memo)
+}
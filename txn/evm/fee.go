@@ -0,0 +1,344 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+const (
+	L1FeeModelNone     = ""
+	L1FeeModelOpStack  = "op"
+	L1FeeModelArbitrum = "arbitrum"
+)
+
+var (
+	opGasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+	arbNodeInterface = common.HexToAddress("0x00000000000000000000000000000000000000C8")
+)
+
+const l1FeeABIJson = `[
+{"type":"function","name":"getL1Fee","stateMutability":"view","inputs":[{"name":"_data","type":"bytes"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"gasEstimateL1Component","stateMutability":"payable","inputs":[{"name":"to","type":"address"},{"name":"contractCreation","type":"bool"},{"name":"data","type":"bytes"}],"outputs":[{"name":"gasEstimateForL1","type":"uint64"},{"name":"baseFee","type":"uint256"},{"name":"l1BaseFeeEstimate","type":"uint256"}]}
+]`
+
+var l1FeeABI, _ = abi.JSON(strings.NewReader(l1FeeABIJson))
+
+// FeeConfig tunes the fee estimation of a chain, zero values fall back to the defaults.
+// Tips are clamped to the tip caps and maxFeePerGas to MaxFeeCap, but a base fee or a
+// legacy gas price above the cap rejects the estimate as the tx could not be mined.
+type FeeConfig struct {
+	// GasMultiplier scales the estimated gas limit, defaults to 1.5
+	GasMultiplier float64 `mapstructure:"gas_multiplier"`
+	// BaseFeeMultiplier scales the next base fee in maxFeePerGas, defaults to 2
+	BaseFeeMultiplier float64 `mapstructure:"base_fee_multiplier"`
+	// GasPriceMultiplier scales the suggested legacy gas price, defaults to 1
+	GasPriceMultiplier float64 `mapstructure:"gas_price_multiplier"`
+	// RewardPercentile is the eth_feeHistory percentile used for the tip, defaults to 50
+	RewardPercentile float64 `mapstructure:"reward_percentile"`
+	// FeeHistoryBlocks is the number of blocks sampled, defaults to 10
+	FeeHistoryBlocks uint64 `mapstructure:"fee_history_blocks"`
+	// caps are in wei, 0 means no cap
+	MinTipCap   uint64 `mapstructure:"min_tip_cap"`
+	MaxTipCap   uint64 `mapstructure:"max_tip_cap"`
+	MaxFeeCap   uint64 `mapstructure:"max_fee_cap"`
+	MaxGasPrice uint64 `mapstructure:"max_gas_price"`
+	// L1FeeModel is "op" for OP-stack chains or "arbitrum", empty for L1 chains. Arbitrum charges
+	// its l1 component as l2 gas, so it is in the gas limit and L1Fee stays 0.
+	L1FeeModel string `mapstructure:"l1_fee_model"`
+}
+
+func (cfg FeeConfig) withDefaults() FeeConfig {
+	if cfg.GasMultiplier <= 0 {
+		cfg.GasMultiplier = 1.5
+	}
+	if cfg.BaseFeeMultiplier <= 0 {
+		cfg.BaseFeeMultiplier = 2
+	}
+	if cfg.GasPriceMultiplier <= 0 {
+		cfg.GasPriceMultiplier = 1
+	}
+	if cfg.RewardPercentile <= 0 || cfg.RewardPercentile > 100 {
+		cfg.RewardPercentile = 50
+	}
+	if cfg.FeeHistoryBlocks == 0 {
+		cfg.FeeHistoryBlocks = 10
+	}
+	return cfg
+}
+
+var feeConfigs = loader.NewChainConfigs[FeeConfig]()
+
+func SetFeeConfig(chainName string, cfg FeeConfig) {
+	feeConfigs.Set(chainName, cfg)
+}
+
+func GetFeeConfig(chainName string) FeeConfig {
+	return feeConfigs.Get(chainName)
+}
+
+// FeeEstimate holds either the legacy GasPrice or the 1559 GasFeeCap and GasTipCap.
+// L1Fee is the data fee an L2 charges on top of gas * price, in wei, 0 when the chain
+// charges it through the gas limit.
+type FeeEstimate struct {
+	Eip1559   bool
+	GasLimit  uint64
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+	BaseFee   *big.Int
+	L1Fee     *big.Int
+	// L1Gas is the l1 component arbitrum includes in the estimated gas, informational only
+	// as GasLimit already covers it
+	L1Gas uint64
+}
+
+// MaxCost is the most the tx can pay in fees
+func (fee *FeeEstimate) MaxCost() *big.Int {
+	price := fee.GasPrice
+	if fee.Eip1559 {
+		price = fee.GasFeeCap
+	}
+	cost := new(big.Int).Mul(price, new(big.Int).SetUint64(fee.GasLimit))
+	if fee.L1Fee != nil {
+		cost.Add(cost, fee.L1Fee)
+	}
+	return cost
+}
+
+type FeeEstimator struct {
+	client    *ethclient.Client
+	chainInfo *loader.ChainInfo
+	cfg       FeeConfig
+}
+
+// NewFeeEstimator uses the config registered by SetFeeConfig for the chain
+func NewFeeEstimator(chainInfo *loader.ChainInfo) (*FeeEstimator, error) {
+	return NewFeeEstimatorWithConfig(chainInfo, GetFeeConfig(chainInfo.Name))
+}
+
+func NewFeeEstimatorWithConfig(chainInfo *loader.ChainInfo, cfg FeeConfig) (*FeeEstimator, error) {
+	client, ok := chainInfo.Client.(*ethclient.Client)
+	if !ok || client == nil {
+		return nil, fmt.Errorf("%v client is not an ethclient: %T", chainInfo.Name, chainInfo.Client)
+	}
+	return &FeeEstimator{
+		client:    client,
+		chainInfo: chainInfo,
+		cfg:       cfg.withDefaults(),
+	}, nil
+}
+
+func capOf(value uint64) *big.Int {
+	if value == 0 {
+		return nil
+	}
+	return new(big.Int).SetUint64(value)
+}
+
+func mulFloat(value *big.Int, multiplier float64) *big.Int {
+	if multiplier == 1 {
+		return new(big.Int).Set(value)
+	}
+	result := new(big.Int).Mul(value, big.NewInt(int64(multiplier*1000)))
+	return result.Div(result, big.NewInt(1000))
+}
+
+// SuggestFees returns the fee fields only, GasLimit and L1Fee are left empty
+func (e *FeeEstimator) SuggestFees(ctx context.Context) (*FeeEstimate, error) {
+	if e.chainInfo.Eip1559 == 0 {
+		return e.suggestLegacy(ctx)
+	}
+	return e.suggest1559(ctx)
+}
+
+func (e *FeeEstimator) suggestLegacy(ctx context.Context) (*FeeEstimate, error) {
+	gasPrice, err := e.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice = mulFloat(gasPrice, e.cfg.GasPriceMultiplier)
+	if maxGasPrice := capOf(e.cfg.MaxGasPrice); maxGasPrice != nil && gasPrice.Cmp(maxGasPrice) > 0 {
+		return nil, fmt.Errorf("%v gas price %v exceeds cap %v", e.chainInfo.Name, gasPrice, maxGasPrice)
+	}
+	return &FeeEstimate{GasPrice: gasPrice}, nil
+}
+
+func (e *FeeEstimator) suggest1559(ctx context.Context) (*FeeEstimate, error) {
+	history, err := e.client.FeeHistory(ctx, e.cfg.FeeHistoryBlocks, nil, []float64{e.cfg.RewardPercentile})
+	if err != nil {
+		return nil, err
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("%v empty fee history", e.chainInfo.Name)
+	}
+	// the last base fee is the one of the next block
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	tips := make([]*big.Int, 0, len(history.Reward))
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil && reward[0].Sign() > 0 {
+			tips = append(tips, reward[0])
+		}
+	}
+	var tip *big.Int
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+		tip = new(big.Int).Set(tips[len(tips)/2])
+	} else {
+		// empty blocks have no rewards, ask the node instead
+		tip, err = e.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if minTip := capOf(e.cfg.MinTipCap); minTip != nil && tip.Cmp(minTip) < 0 {
+		tip = minTip
+	}
+	if maxTip := capOf(e.cfg.MaxTipCap); maxTip != nil && tip.Cmp(maxTip) > 0 {
+		tip = maxTip
+	}
+
+	feeCap := new(big.Int).Add(mulFloat(baseFee, e.cfg.BaseFeeMultiplier), tip)
+	if maxFeeCap := capOf(e.cfg.MaxFeeCap); maxFeeCap != nil && feeCap.Cmp(maxFeeCap) > 0 {
+		if baseFee.Cmp(maxFeeCap) >= 0 {
+			return nil, fmt.Errorf("%v base fee %v exceeds fee cap %v", e.chainInfo.Name, baseFee, maxFeeCap)
+		}
+		feeCap = maxFeeCap
+		if maxTip := new(big.Int).Sub(feeCap, baseFee); tip.Cmp(maxTip) > 0 {
+			tip = maxTip
+		}
+	}
+	return &FeeEstimate{
+		Eip1559:   true,
+		GasFeeCap: feeCap,
+		GasTipCap: tip,
+		BaseFee:   baseFee,
+	}, nil
+}
+
+// EstimateFees estimates the gas limit, the fees and the L1 data fee of a call
+func (e *FeeEstimator) EstimateFees(ctx context.Context, from string, to string, value *big.Int, data []byte) (*FeeEstimate, error) {
+	fee, err := e.SuggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f := common.HexToAddress(strings.TrimSpace(from))
+	t := common.HexToAddress(strings.TrimSpace(to))
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	gas, err := e.client.EstimateGas(ctx, ethereum.CallMsg{From: f, To: &t, Value: value, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	fee.GasLimit = uint64(float64(gas) * e.cfg.GasMultiplier)
+
+	fee.L1Fee, err = e.estimateL1Fee(ctx, f, t, value, data, fee)
+	if err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+func (e *FeeEstimator) estimateL1Fee(ctx context.Context, from common.Address, to common.Address, value *big.Int, data []byte, fee *FeeEstimate) (*big.Int, error) {
+	switch strings.ToLower(strings.TrimSpace(e.cfg.L1FeeModel)) {
+	case L1FeeModelNone:
+		return big.NewInt(0), nil
+	case L1FeeModelOpStack:
+		nonce, err := e.client.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		// the oracle prices the unsigned tx, the signature adds a constant overhead it accounts for
+		var tx *types.Transaction
+		if fee.Eip1559 {
+			tx = types.NewTx(&types.DynamicFeeTx{
+				ChainID:   big.NewInt(e.chainInfo.GetInt64ChainId()),
+				Nonce:     nonce,
+				GasTipCap: fee.GasTipCap,
+				GasFeeCap: fee.GasFeeCap,
+				Gas:       fee.GasLimit,
+				To:        &to,
+				Value:     value,
+				Data:      data,
+			})
+		} else {
+			tx = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: fee.GasPrice, Gas: fee.GasLimit, To: &to, Value: value, Data: data})
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		result, err := e.callL1Fee(ctx, opGasPriceOracle, "getL1Fee", raw)
+		if err != nil {
+			return nil, err
+		}
+		return result[0].(*big.Int), nil
+	case L1FeeModelArbitrum:
+		// eth_estimateGas already includes the l1 component as extra l2 gas, so GasLimit covers it
+		result, err := e.callL1Fee(ctx, arbNodeInterface, "gasEstimateL1Component", to, false, data)
+		if err != nil {
+			return nil, err
+		}
+		fee.L1Gas = result[0].(uint64)
+		return big.NewInt(0), nil
+	default:
+		return nil, fmt.Errorf("unsupport l1 fee model: %s", e.cfg.L1FeeModel)
+	}
+}
+
+func (e *FeeEstimator) callL1Fee(ctx context.Context, contract common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	input, err := l1FeeABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := e.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: input}, nil)
+	if err != nil {
+		log.Errorf("%v estimate l1 fee error %v", e.chainInfo.Name, err)
+		return nil, err
+	}
+	return l1FeeABI.Unpack(method, output)
+}
+
+// WithFee embeds the fee fields into a body returned by the builders of this package,
+// the gas is replaced only if fee has a gas limit.
+func WithFee(body []byte, fee *FeeEstimate) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	if fee.GasLimit > 0 {
+		m["gas"] = fmt.Sprintf("0x%x", fee.GasLimit)
+	}
+	if fee.Eip1559 {
+		delete(m, "gasPrice")
+		m["maxFeePerGas"] = fmt.Sprintf("0x%x", fee.GasFeeCap)
+		m["maxPriorityFeePerGas"] = fmt.Sprintf("0x%x", fee.GasTipCap)
+	} else {
+		delete(m, "maxFeePerGas")
+		delete(m, "maxPriorityFeePerGas")
+		m["gasPrice"] = fmt.Sprintf("0x%x", fee.GasPrice)
+	}
+	return json.Marshal(m)
+}
+
+func ToBodyWithFee(to string, value *big.Int, input []byte, fee *FeeEstimate) ([]byte, error) {
+	body, err := ToBody(to, value, input, fee.GasLimit)
+	if err != nil {
+		return nil, err
+	}
+	return WithFee(body, fee)
+}
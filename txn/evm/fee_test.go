@@ -0,0 +1,125 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeeEstimator(t *testing.T, chainInfo *loader.ChainInfo, cfg FeeConfig) *FeeEstimator {
+	estimator, err := NewFeeEstimatorWithConfig(chainInfo, cfg)
+	require.NoError(t, err)
+	return estimator
+}
+
+func TestFeeEstimator(t *testing.T) {
+	ctx := context.TODO()
+	sim, err := rpctest.NewSimulatedEvm(2)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	from, to := sim.Address(0).Hex(), sim.Address(1).Hex()
+	// a mined tx gives the fee history a reward to sample
+	body, err := TransferBody(sim.Client, from, to, big.NewInt(1))
+	require.NoError(t, err)
+	_, err = sim.SendBody(ctx, 0, body)
+	require.NoError(t, err)
+
+	fee, err := newFeeEstimator(t, sim.ChainInfo, FeeConfig{}).EstimateFees(ctx, from, to, big.NewInt(1), nil)
+	require.NoError(t, err)
+	assert.True(t, fee.Eip1559)
+	assert.Equal(t, uint64(31500), fee.GasLimit)
+	assert.Equal(t, 0, fee.GasFeeCap.Cmp(new(big.Int).Add(new(big.Int).Mul(fee.BaseFee, big.NewInt(2)), fee.GasTipCap)))
+	assert.Equal(t, 0, fee.L1Fee.Sign())
+	assert.Equal(t, new(big.Int).Mul(fee.GasFeeCap, big.NewInt(31500)), fee.MaxCost())
+
+	opFee := FeeEstimate{Eip1559: true, GasLimit: 100, GasFeeCap: big.NewInt(3), L1Fee: big.NewInt(50)}
+	assert.Equal(t, big.NewInt(350), opFee.MaxCost())
+
+	maxFeeCap := fee.BaseFee.Uint64() + 1
+	capped, err := newFeeEstimator(t, sim.ChainInfo, FeeConfig{MaxFeeCap: maxFeeCap}).SuggestFees(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, maxFeeCap, capped.GasFeeCap.Uint64())
+	assert.Equal(t, uint64(1), capped.GasTipCap.Uint64())
+	_, err = newFeeEstimator(t, sim.ChainInfo, FeeConfig{MaxFeeCap: fee.BaseFee.Uint64()}).SuggestFees(ctx)
+	assert.Error(t, err)
+
+	body, err = ToBodyWithFee(to, big.NewInt(1), nil, fee)
+	require.NoError(t, err)
+	var m map[string]string
+	require.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, "0x7b0c", m["gas"])
+	assert.Contains(t, m, "maxFeePerGas")
+	assert.NotContains(t, m, "gasPrice")
+
+	legacyChain := *sim.ChainInfo
+	legacyChain.Eip1559 = 0
+	legacy, err := newFeeEstimator(t, &legacyChain, FeeConfig{}).SuggestFees(ctx)
+	require.NoError(t, err)
+	assert.False(t, legacy.Eip1559)
+	body, err = WithFee(body, legacy)
+	require.NoError(t, err)
+	m = nil
+	require.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, "0x7b0c", m["gas"])
+	assert.NotContains(t, m, "maxFeePerGas")
+	assert.Equal(t, "0x"+legacy.GasPrice.Text(16), m["gasPrice"])
+
+	_, err = NewFeeEstimatorWithConfig(&loader.ChainInfo{Name: "NoClient"}, FeeConfig{})
+	assert.Error(t, err)
+}
+
+func TestArbitrumL1Gas(t *testing.T) {
+	l1FeeOutput, err := l1FeeABI.Methods["gasEstimateL1Component"].Outputs.Pack(uint64(4000), big.NewInt(10), big.NewInt(30))
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		switch req.Method {
+		case "eth_gasPrice":
+			result = "0xa"
+		case "eth_estimateGas":
+			// 21000 of l2 execution and the 4000 of the l1 component
+			result = "0x61a8"
+		case "eth_call":
+			var call struct {
+				To string `json:"to"`
+			}
+			require.NoError(t, json.Unmarshal(req.Params[0], &call))
+			assert.Equal(t, strings.ToLower(arbNodeInterface.Hex()), strings.ToLower(call.To))
+			result = hexutil.Bytes(l1FeeOutput)
+		default:
+			t.Fatalf("unexpected method %s", req.Method)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result})
+	}))
+	defer server.Close()
+
+	client, err := ethclient.Dial(server.URL)
+	require.NoError(t, err)
+	chainInfo := &loader.ChainInfo{Name: "ArbitrumMainnet", Backend: loader.EthereumBackend, Client: client}
+	fee, err := newFeeEstimator(t, chainInfo, FeeConfig{L1FeeModel: L1FeeModelArbitrum}).EstimateFees(context.TODO(),
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", big.NewInt(1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4000), fee.L1Gas)
+	assert.Equal(t, uint64(37500), fee.GasLimit)
+	// the l1 component is part of the gas limit and must not be counted twice
+	assert.Equal(t, 0, fee.L1Fee.Sign())
+	assert.Equal(t, big.NewInt(375000), fee.MaxCost())
+}
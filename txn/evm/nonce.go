@@ -0,0 +1,350 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+// ReplacementBumpPercent is the fee increase nodes require to replace a pending tx
+const ReplacementBumpPercent = 10
+
+// NonceStore persists the next nonce of a sender so that a restart does not reuse
+// nonces a lagging node has not seen yet.
+type NonceStore interface {
+	LoadNonce(ctx context.Context, chainName string, sender string) (uint64, bool, error)
+	SaveNonce(ctx context.Context, chainName string, sender string, next uint64) error
+}
+
+type MemoryNonceStore struct {
+	nonces map[string]uint64
+	mutex  *sync.Mutex
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: make(map[string]uint64),
+		mutex:  &sync.Mutex{},
+	}
+}
+
+func (s *MemoryNonceStore) LoadNonce(ctx context.Context, chainName string, sender string) (uint64, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	next, ok := s.nonces[chainName+"/"+strings.ToLower(sender)]
+	return next, ok, nil
+}
+
+func (s *MemoryNonceStore) SaveNonce(ctx context.Context, chainName string, sender string, next uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nonces[chainName+"/"+strings.ToLower(sender)] = next
+	return nil
+}
+
+type InflightNonce struct {
+	Nonce      uint64
+	Hash       string
+	AcquiredAt time.Time
+	// SentAt is zero until MarkSent
+	SentAt time.Time
+}
+
+type NonceStatus struct {
+	// Latest is the nonce of the next tx to be mined, Pending counts the node's mempool too
+	Latest  uint64
+	Pending uint64
+	Next    uint64
+	// Gaps are nonces below Next the node does not have and nobody is about to send,
+	// every later tx waits for them
+	Gaps []uint64
+	// Stuck are sent txs still unmined after StuckAfter, candidates for ReplacementBody
+	Stuck []InflightNonce
+}
+
+// NonceManager hands out the nonces of one sender on one chain. It must be the only
+// writer of the sender, Sync picks up txs sent by others but races with them.
+type NonceManager struct {
+	client     *ethclient.Client
+	chainName  string
+	sender     common.Address
+	store      NonceStore
+	StuckAfter time.Duration
+
+	synced   bool
+	next     uint64
+	released []uint64
+	inflight map[uint64]*InflightNonce
+	mutex    *sync.Mutex
+}
+
+func NewNonceManager(client *ethclient.Client, chainName string, sender common.Address, store NonceStore) *NonceManager {
+	if store == nil {
+		store = NewMemoryNonceStore()
+	}
+	return &NonceManager{
+		client:     client,
+		chainName:  chainName,
+		sender:     sender,
+		store:      store,
+		StuckAfter: 3 * time.Minute,
+		inflight:   make(map[uint64]*InflightNonce),
+		mutex:      &sync.Mutex{},
+	}
+}
+
+// Next reserves a nonce, it must be followed by MarkSent or Release
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.synced {
+		if _, _, err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	var nonce uint64
+	if len(m.released) > 0 {
+		nonce, m.released = m.released[0], m.released[1:]
+	} else {
+		nonce = m.next
+		m.next++
+		if err := m.store.SaveNonce(ctx, m.chainName, m.sender.Hex(), m.next); err != nil {
+			m.next--
+			return 0, err
+		}
+	}
+	m.inflight[nonce] = &InflightNonce{Nonce: nonce, AcquiredAt: time.Now()}
+	return nonce, nil
+}
+
+func (m *NonceManager) MarkSent(nonce uint64, hash string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	inflight, ok := m.inflight[nonce]
+	if !ok {
+		inflight = &InflightNonce{Nonce: nonce, AcquiredAt: time.Now()}
+		m.inflight[nonce] = inflight
+	}
+	inflight.Hash = hash
+	inflight.SentAt = time.Now()
+}
+
+// Release gives back a nonce whose tx was never broadcast, the next Next reuses it
+func (m *NonceManager) Release(nonce uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	inflight, ok := m.inflight[nonce]
+	if !ok || !inflight.SentAt.IsZero() {
+		return
+	}
+	delete(m.inflight, nonce)
+	m.released = append(m.released, nonce)
+	sort.Slice(m.released, func(i, j int) bool { return m.released[i] < m.released[j] })
+}
+
+// Sync forgets mined nonces and moves past nonces used outside of the manager. The next
+// nonce never goes back, use Reset for that.
+func (m *NonceManager) Sync(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, _, err := m.sync(ctx)
+	return err
+}
+
+func (m *NonceManager) sync(ctx context.Context) (uint64, uint64, error) {
+	latest, pending, err := m.nodeNonces(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !m.synced {
+		stored, ok, err := m.store.LoadNonce(ctx, m.chainName, m.sender.Hex())
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok && stored > m.next {
+			m.next = stored
+		}
+	}
+
+	for nonce := range m.inflight {
+		if nonce < latest {
+			delete(m.inflight, nonce)
+		}
+	}
+	released := m.released[:0]
+	for _, nonce := range m.released {
+		if nonce >= pending {
+			released = append(released, nonce)
+		}
+	}
+	m.released = released
+	if pending > m.next {
+		m.next = pending
+		if err := m.store.SaveNonce(ctx, m.chainName, m.sender.Hex(), m.next); err != nil {
+			return 0, 0, err
+		}
+	}
+	m.synced = true
+	return latest, pending, nil
+}
+
+// Reset drops every nonce the node does not know about and restarts from its pending nonce,
+// txs still held elsewhere in the network with those nonces will be replaced.
+func (m *NonceManager) Reset(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, pending, err := m.nodeNonces(ctx)
+	if err != nil {
+		return err
+	}
+	if m.next != pending {
+		log.Warnf("%v %v reset nonce %d to %d", m.chainName, m.sender.Hex(), m.next, pending)
+	}
+	for nonce := range m.inflight {
+		if nonce >= pending {
+			delete(m.inflight, nonce)
+		}
+	}
+	m.released = nil
+	m.next = pending
+	m.synced = true
+	return m.store.SaveNonce(ctx, m.chainName, m.sender.Hex(), m.next)
+}
+
+// Status syncs and reports the gaps and stuck txs of the sender
+func (m *NonceManager) Status(ctx context.Context) (*NonceStatus, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	latest, pending, err := m.sync(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &NonceStatus{Latest: latest, Pending: pending, Next: m.next}
+	for nonce := pending; nonce < m.next; nonce++ {
+		// a sent tx above the pending nonce may just be queued behind the gap
+		inflight, ok := m.inflight[nonce]
+		if !ok || (nonce == pending && !inflight.SentAt.IsZero()) {
+			status.Gaps = append(status.Gaps, nonce)
+		}
+	}
+	for _, inflight := range m.inflight {
+		if !inflight.SentAt.IsZero() && time.Since(inflight.SentAt) >= m.StuckAfter {
+			status.Stuck = append(status.Stuck, *inflight)
+		}
+	}
+	sort.Slice(status.Stuck, func(i, j int) bool { return status.Stuck[i].Nonce < status.Stuck[j].Nonce })
+	return status, nil
+}
+
+func (m *NonceManager) nodeNonces(ctx context.Context) (uint64, uint64, error) {
+	latest, err := m.client.NonceAt(ctx, m.sender, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%v get nonce of %v error %w", m.chainName, m.sender.Hex(), err)
+	}
+	pending, err := m.client.PendingNonceAt(ctx, m.sender)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%v get pending nonce of %v error %w", m.chainName, m.sender.Hex(), err)
+	}
+	return latest, pending, nil
+}
+
+// NonceManagerPool keeps one NonceManager per chain and sender
+type NonceManagerPool struct {
+	store    NonceStore
+	managers map[string]*NonceManager
+	mutex    *sync.Mutex
+}
+
+func NewNonceManagerPool(store NonceStore) *NonceManagerPool {
+	return &NonceManagerPool{
+		store:    store,
+		managers: make(map[string]*NonceManager),
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (p *NonceManagerPool) Get(client *ethclient.Client, chainName string, sender common.Address) *NonceManager {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	key := chainName + "/" + sender.Hex()
+	manager, ok := p.managers[key]
+	if !ok {
+		manager = NewNonceManager(client, chainName, sender, p.store)
+		p.managers[key] = manager
+	}
+	return manager
+}
+
+func WithNonce(body []byte, nonce uint64) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	m["nonce"] = fmt.Sprintf("0x%x", nonce)
+	return json.Marshal(m)
+}
+
+// ReplacementBody rebuilds a sent body with the same nonce and fees bumped by at least
+// ReplacementBumpPercent, or raised to fee when it is higher. fee may be nil.
+func ReplacementBody(body []byte, nonce uint64, fee *FeeEstimate) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	m["nonce"] = fmt.Sprintf("0x%x", nonce)
+
+	bump := func(field string, floor *big.Int) error {
+		value, ok := m[field].(string)
+		if !ok {
+			return fmt.Errorf("missing %s", field)
+		}
+		old, err := hexutil.DecodeBig(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+		bumped := new(big.Int).Mul(old, big.NewInt(100+ReplacementBumpPercent))
+		bumped.Div(bumped, big.NewInt(100))
+		bumped.Add(bumped, common.Big1)
+		if floor != nil && floor.Cmp(bumped) > 0 {
+			bumped = floor
+		}
+		m[field] = fmt.Sprintf("0x%x", bumped)
+		return nil
+	}
+
+	var err error
+	if _, ok := m["maxFeePerGas"]; ok {
+		var feeCap, tipCap *big.Int
+		if fee != nil && fee.Eip1559 {
+			feeCap, tipCap = fee.GasFeeCap, fee.GasTipCap
+		}
+		if err = bump("maxFeePerGas", feeCap); err == nil {
+			err = bump("maxPriorityFeePerGas", tipCap)
+		}
+	} else {
+		var gasPrice *big.Int
+		if fee != nil && !fee.Eip1559 {
+			gasPrice = fee.GasPrice
+		}
+		err = bump("gasPrice", gasPrice)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fee != nil && fee.GasLimit > 0 {
+		m["gas"] = fmt.Sprintf("0x%x", fee.GasLimit)
+	}
+	return json.Marshal(m)
+}
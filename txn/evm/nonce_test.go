@@ -0,0 +1,111 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/owlto-dao/utils-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.TODO()
+	sim, err := rpctest.NewSimulatedEvm(2)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	sender, to := sim.Address(0), sim.Address(1)
+	send := func(nonce uint64) {
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(10e9),
+		}), types.LatestSignerForChainID(big.NewInt(rpctest.SimulatedChainId)), sim.Accounts[0])
+		require.NoError(t, err)
+		require.NoError(t, sim.Client.SendTransaction(ctx, tx))
+	}
+
+	store := NewMemoryNonceStore()
+	mgr := NewNonceManagerPool(store).Get(sim.Client, "Simulated", sender)
+	var nonces []uint64
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := mgr.Next(ctx)
+			assert.NoError(t, err)
+			mutex.Lock()
+			nonces = append(nonces, nonce)
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	assert.Equal(t, []uint64{0, 1, 2}, nonces)
+
+	send(0)
+	mgr.MarkSent(0, "0x00")
+	mgr.Release(1)
+	mgr.StuckAfter = 0
+	status, err := mgr.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), status.Pending)
+	assert.Equal(t, []uint64{1}, status.Gaps)
+	assert.Len(t, status.Stuck, 1)
+
+	nonce, err := mgr.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+	send(1)
+	mgr.MarkSent(1, "0x01")
+	send(2)
+	mgr.MarkSent(2, "0x02")
+	_, err = sim.Commit()
+	require.NoError(t, err)
+	status, err = mgr.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), status.Latest)
+	assert.Empty(t, status.Gaps)
+	assert.Empty(t, status.Stuck)
+
+	// a restart trusts the store over a lagging node until Reset
+	require.NoError(t, store.SaveNonce(ctx, "Simulated", sender.Hex(), 5))
+	restarted := NewNonceManager(sim.Client, "Simulated", sender, store)
+	status, err = restarted.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 4}, status.Gaps)
+	require.NoError(t, restarted.Reset(ctx))
+	nonce, err = restarted.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+}
+
+func TestReplacementBody(t *testing.T) {
+	body := []byte(`{"to":"0x0000000000000000000000000000000000000001","gas":"0x5208","value":"0x0","gasPrice":"0x64"}`)
+	replacement, err := ReplacementBody(body, 7, nil)
+	require.NoError(t, err)
+	var m map[string]string
+	require.NoError(t, json.Unmarshal(replacement, &m))
+	assert.Equal(t, "0x7", m["nonce"])
+	assert.Equal(t, "0x6f", m["gasPrice"])
+
+	body = []byte(`{"to":"0x0000000000000000000000000000000000000001","gas":"0x5208","value":"0x0","maxFeePerGas":"0x64","maxPriorityFeePerGas":"0xa"}`)
+	fee := &FeeEstimate{Eip1559: true, GasFeeCap: big.NewInt(200), GasTipCap: big.NewInt(5)}
+	replacement, err = ReplacementBody(body, 7, fee)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(replacement, &m))
+	assert.Equal(t, "0xc8", m["maxFeePerGas"])
+	assert.Equal(t, "0xc", m["maxPriorityFeePerGas"])
+	_, err = ReplacementBody([]byte(`{"gas":"0x5208"}`), 7, nil)
+	assert.Error(t, err)
+}
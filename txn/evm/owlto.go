@@ -0,0 +1,109 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/owlto-dao/utils-go/abi/depositor"
+	owlto20 "github.com/owlto-dao/utils-go/abi/owlto"
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/owlto-dao/utils-go/util"
+)
+
+// UnestimatedCallGas is the gas of a call following an approve in the same batch, it cannot
+// be estimated before the approve is mined
+const UnestimatedCallGas = 300_000
+
+// OwltoTransferBodies calls transfer on the owlto contract of the chain, its transfer contract.
+// The bodies are to be sent in order: an approve of the contract when the erc20 allowance of
+// the sender is short, then the transfer, which carries the amount as value for the native token.
+func OwltoTransferBodies(ctx context.Context, evmRpc *rpc.EvmRpc, senderAddr string, tokenAddr string, makerAddr string, amount *big.Int, targetAddr string) ([][]byte, error) {
+	chainInfo := evmRpc.GetChainInfo()
+	if !chainInfo.TransferContractAddress.Valid || chainInfo.TransferContractAddress.String == "" {
+		return nil, fmt.Errorf("%v has no transfer contract", chainInfo.Name)
+	}
+
+	owltoAbi, err := owlto20.Owlto20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return owltoCallBodies(ctx, evmRpc, owltoAbi, chainInfo.TransferContractAddress.String, senderAddr, tokenAddr, amount,
+		"transfer", strings.TrimSpace(targetAddr), tokenAddress(tokenAddr), common.HexToAddress(strings.TrimSpace(makerAddr)), amount)
+}
+
+// DepositorDepositBodies calls deposit on the depositor contract of the chain, its deposit
+// contract, with the same approve and value handling as OwltoTransferBodies.
+func DepositorDepositBodies(ctx context.Context, evmRpc *rpc.EvmRpc, senderAddr string, tokenAddr string, makerAddr string, amount *big.Int, targetAddr string, destination *big.Int, channel *big.Int) ([][]byte, error) {
+	chainInfo := evmRpc.GetChainInfo()
+	if !chainInfo.DepositContractAddress.Valid || chainInfo.DepositContractAddress.String == "" {
+		return nil, fmt.Errorf("%v has no deposit contract", chainInfo.Name)
+	}
+
+	depositorAbi, err := depositor.DepositorMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return owltoCallBodies(ctx, evmRpc, depositorAbi, chainInfo.DepositContractAddress.String, senderAddr, tokenAddr, amount,
+		"deposit", strings.TrimSpace(targetAddr), tokenAddress(tokenAddr), common.HexToAddress(strings.TrimSpace(makerAddr)), amount, destination, channel)
+}
+
+func tokenAddress(tokenAddr string) common.Address {
+	if util.IsHexStringZero(tokenAddr) {
+		return common.Address{}
+	}
+	return common.HexToAddress(strings.TrimSpace(tokenAddr))
+}
+
+func owltoCallBodies(ctx context.Context, evmRpc *rpc.EvmRpc, contractAbi *abi.ABI, contractAddr string, senderAddr string, tokenAddr string, amount *big.Int, method string, args ...interface{}) ([][]byte, error) {
+	senderAddr = strings.TrimSpace(senderAddr)
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	contractAddr = strings.TrimSpace(contractAddr)
+
+	data, err := contractAbi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	client := evmRpc.GetClient()
+
+	if util.IsHexStringZero(tokenAddr) {
+		gas, err := EstimateGas(client, senderAddr, contractAddr, amount, data)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ToBody(contractAddr, amount, data, gas)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{body}, nil
+	}
+
+	allowance, err := evmRpc.GetAllowance(ctx, senderAddr, tokenAddr, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	if allowance.Cmp(amount) >= 0 {
+		gas, err := EstimateGas(client, senderAddr, contractAddr, nil, data)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ToBody(contractAddr, nil, data, gas)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{body}, nil
+	}
+
+	approve, err := Erc20ApproveBody(client, senderAddr, tokenAddr, contractAddr, amount)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ToBody(contractAddr, nil, data, UnestimatedCallGas)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{approve, body}, nil
+}
@@ -0,0 +1,87 @@
+package evm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/owlto-dao/utils-go/abi/depositor"
+	"github.com/owlto-dao/utils-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwltoTransferBodies(t *testing.T) {
+	ctx := context.TODO()
+	sim, err := rpctest.NewSimulatedEvm(2)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	sender, maker := sim.Address(0).Hex(), sim.Address(1).Hex()
+	tokenAddr, token, err := sim.DeployErc20(ctx, 0, "USD Coin", "USDC", 6, big.NewInt(1e12))
+	require.NoError(t, err)
+	owltoAddr, _, err := sim.DeployOwlto20(ctx, 0)
+	require.NoError(t, err)
+	sim.ChainInfo.TransferContractAddress = sql.NullString{String: owltoAddr.Hex(), Valid: true}
+	target := "0x5e809A85Aa182A9921EDD10a4163745bb3e36284"
+
+	send := func(bodies [][]byte) {
+		for _, body := range bodies {
+			receipt, err := sim.SendBody(ctx, 0, body)
+			require.NoError(t, err)
+			require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		}
+	}
+
+	bodies, err := OwltoTransferBodies(ctx, sim.Rpc, sender, tokenAddr.Hex(), maker, big.NewInt(1000), target)
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	send(bodies)
+	balance, err := token.BalanceOf(nil, sim.Address(1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), balance.Int64())
+
+	// the approve is not needed while the allowance covers the amount
+	approve, err := Erc20ApproveBody(sim.Client, sender, tokenAddr.Hex(), owltoAddr.Hex(), big.NewInt(5000))
+	require.NoError(t, err)
+	send([][]byte{approve})
+	bodies, err = OwltoTransferBodies(ctx, sim.Rpc, sender, tokenAddr.Hex(), maker, big.NewInt(5000), target)
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	send(bodies)
+
+	before, err := sim.Client.BalanceAt(ctx, sim.Address(1), nil)
+	require.NoError(t, err)
+	bodies, err = OwltoTransferBodies(ctx, sim.Rpc, sender, "0x0000000000000000000000000000000000000000", maker, big.NewInt(1e15), target)
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	send(bodies)
+	after, err := sim.Client.BalanceAt(ctx, sim.Address(1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1e15), new(big.Int).Sub(after, before).Int64())
+
+	// the depositor is not deployed, a native deposit to the address still estimates
+	depositAddr := common.HexToAddress("0x00000000000000000000000000000000000d3905")
+	sim.ChainInfo.DepositContractAddress = sql.NullString{String: depositAddr.Hex(), Valid: true}
+	bodies, err = DepositorDepositBodies(ctx, sim.Rpc, sender, "0x0000000000000000000000000000000000000000", maker, big.NewInt(7), target, big.NewInt(8453), big.NewInt(2))
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	var m struct {
+		Value hexutil.Big   `json:"value"`
+		Input hexutil.Bytes `json:"input"`
+	}
+	require.NoError(t, json.Unmarshal(bodies[0], &m))
+	assert.Equal(t, int64(7), m.Value.ToInt().Int64())
+	depositorAbi, err := depositor.DepositorMetaData.GetAbi()
+	require.NoError(t, err)
+	args, err := depositorAbi.Methods["deposit"].Inputs.Unpack(m.Input[4:])
+	require.NoError(t, err)
+	assert.Equal(t, target, args[0])
+	assert.Equal(t, common.Address{}, args[1])
+	assert.Equal(t, int64(8453), args[4].(*big.Int).Int64())
+}
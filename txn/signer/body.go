@@ -0,0 +1,163 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gagliardetto/solana-go"
+	"github.com/owlto-dao/utils-go/loader"
+	sol "github.com/owlto-dao/utils-go/txn/solana"
+)
+
+// evmBody is the json produced by evm.ToBody, evm.WithFee and evm.WithNonce
+type evmBody struct {
+	To                   *common.Address `json:"to"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	Value                *hexutil.Big    `json:"value"`
+	Input                hexutil.Bytes   `json:"input"`
+	Nonce                *hexutil.Uint64 `json:"nonce"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// SignEvmBody signs an evm body carrying its nonce and fees, it returns the raw tx and its hash.
+// Bodies with maxFeePerGas become dynamic fee txs, the others eip-155 legacy txs.
+func SignEvmBody(ctx context.Context, signer Signer, chainInfo *loader.ChainInfo, body []byte) ([]byte, string, error) {
+	var b evmBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, "", err
+	}
+	if b.Nonce == nil || b.Gas == nil {
+		return nil, "", fmt.Errorf("evm body without nonce or gas")
+	}
+	chainId, err := strconv.ParseInt(chainInfo.RealChainId, 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("%v invalid chain id %v", chainInfo.Name, chainInfo.RealChainId)
+	}
+	value := big.NewInt(0)
+	if b.Value != nil {
+		value = b.Value.ToInt()
+	}
+
+	var txData types.TxData
+	if b.MaxFeePerGas != nil && b.MaxPriorityFeePerGas != nil {
+		txData = &types.DynamicFeeTx{
+			ChainID:   big.NewInt(chainId),
+			Nonce:     uint64(*b.Nonce),
+			GasTipCap: b.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: b.MaxFeePerGas.ToInt(),
+			Gas:       uint64(*b.Gas),
+			To:        b.To,
+			Value:     value,
+			Data:      b.Input,
+		}
+	} else if b.GasPrice != nil {
+		txData = &types.LegacyTx{
+			Nonce:    uint64(*b.Nonce),
+			GasPrice: b.GasPrice.ToInt(),
+			Gas:      uint64(*b.Gas),
+			To:       b.To,
+			Value:    value,
+			Data:     b.Input,
+		}
+	} else {
+		return nil, "", fmt.Errorf("evm body without fee")
+	}
+
+	txSigner := types.LatestSignerForChainID(big.NewInt(chainId))
+	tx := types.NewTx(txData)
+	hash := txSigner.Hash(tx)
+	signature, err := signer.Sign(ctx, hash[:])
+	if err != nil {
+		return nil, "", err
+	}
+	tx, err = tx.WithSignature(txSigner, signature)
+	if err != nil {
+		return nil, "", err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, tx.Hash().Hex(), nil
+}
+
+// SignSolanaBody builds the tx paid by the signer, the body keypairs co-sign it. Bodies with
+// lookup tables become v0 txs. It returns the raw tx and its first signature.
+func SignSolanaBody(ctx context.Context, signer Signer, body []byte, recentBlockhash solana.Hash) ([]byte, string, error) {
+	var b sol.SolanaBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, "", err
+	}
+	payer, err := solana.PublicKeyFromBase58(signer.Address())
+	if err != nil {
+		return nil, "", err
+	}
+	opts := []solana.TransactionOption{solana.TransactionPayer(payer)}
+	if len(b.LookupTables) > 0 {
+		opts = append(opts, solana.TransactionAddressTables(b.LookupTables))
+	}
+	tx, err := solana.NewTransaction(b.ToInstructions(), recentBlockhash, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return nil, "", err
+	}
+
+	keypairs := make(map[solana.PublicKey]solana.PrivateKey, len(b.Keypairs))
+	for _, keypair := range b.Keypairs {
+		keypairs[keypair.PublicKey] = keypair.PrivateKey
+	}
+	for _, pubkey := range tx.Message.Signers() {
+		var signature solana.Signature
+		if pubkey == payer {
+			rawSignature, err := signer.Sign(ctx, message)
+			if err != nil {
+				return nil, "", err
+			}
+			if len(rawSignature) != len(signature) {
+				return nil, "", fmt.Errorf("solana signature must be %d bytes, got %d", len(signature), len(rawSignature))
+			}
+			copy(signature[:], rawSignature)
+		} else if key, ok := keypairs[pubkey]; ok {
+			if signature, err = key.Sign(message); err != nil {
+				return nil, "", err
+			}
+		} else {
+			return nil, "", fmt.Errorf("missing signer %s", pubkey)
+		}
+		tx.Signatures = append(tx.Signatures, signature)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, tx.Signatures[0].String(), nil
+}
+
+// SignStarknetHash signs a starknet tx hash into the r, s felts account contracts expect
+func SignStarknetHash(ctx context.Context, signer Signer, hash *felt.Felt) ([]*felt.Felt, error) {
+	payload := hash.Bytes()
+	signature, err := signer.Sign(ctx, payload[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("starknet signature must be 64 bytes, got %d", len(signature))
+	}
+	return []*felt.Felt{
+		new(felt.Felt).SetBytes(signature[:32]),
+		new(felt.Felt).SetBytes(signature[32:]),
+	}, nil
+}
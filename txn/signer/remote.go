@@ -0,0 +1,95 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/network"
+)
+
+// SignRequest is posted to the remote signer, the payload is the same as for Signer.Sign
+type SignRequest struct {
+	Backend loader.Backend `json:"backend"`
+	Address string         `json:"address"`
+	Payload hexutil.Bytes  `json:"payload"`
+}
+
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// RemoteSigner asks a signing service holding the key, the protocol is a json POST of
+// SignRequest answered with a SignResponse.
+type RemoteSigner struct {
+	url     string
+	backend loader.Backend
+	address string
+}
+
+func NewRemoteSigner(url string, backend loader.Backend, address string) *RemoteSigner {
+	return &RemoteSigner{url: strings.TrimSpace(url), backend: backend, address: strings.TrimSpace(address)}
+}
+
+func (s *RemoteSigner) Backend() loader.Backend {
+	return s.backend
+}
+
+func (s *RemoteSigner) Address() string {
+	return s.address
+}
+
+func (s *RemoteSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	var rsp SignResponse
+	req := SignRequest{Backend: s.backend, Address: s.address, Payload: payload}
+	if err := network.RequestWithContext(ctx, s.url, req, &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Error != "" {
+		return nil, fmt.Errorf("remote sign error: %s", rsp.Error)
+	}
+	if len(rsp.Signature) == 0 {
+		return nil, fmt.Errorf("remote sign empty signature")
+	}
+	return rsp.Signature, nil
+}
+
+// SignHandler serves the remote signer protocol with local signers, as a stand-in for
+// the signing service in tests or as the base of one.
+func SignHandler(signers ...Signer) http.Handler {
+	byAddress := make(map[string]Signer, len(signers))
+	for _, signer := range signers {
+		byAddress[signerKey(signer.Backend(), signer.Address())] = signer
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var rsp SignResponse
+		var req SignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			rsp.Error = err.Error()
+		} else if signer, ok := byAddress[signerKey(req.Backend, req.Address)]; !ok {
+			rsp.Error = fmt.Sprintf("unknown signer %d %s", req.Backend, req.Address)
+		} else if signature, err := signer.Sign(r.Context(), req.Payload); err != nil {
+			rsp.Error = err.Error()
+		} else {
+			rsp.Signature = signature
+		}
+		json.NewEncoder(w).Encode(rsp)
+	})
+}
+
+// signerKey lowercases hex addresses, base58 ones are case sensitive
+func signerKey(backend loader.Backend, address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		address = strings.ToLower(address)
+	}
+	return fmt.Sprintf("%d/%s", backend, address)
+}
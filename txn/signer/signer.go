@@ -0,0 +1,134 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gagliardetto/solana-go"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+// Signer signs the payload of one account. The payload depends on the backend:
+// the 32 bytes signing hash for evm, answered with a 65 bytes [R || S || V] signature,
+// the serialized message for solana, answered with a 64 bytes ed25519 signature, and the
+// 32 bytes tx hash for starknet, answered with the 64 bytes r || s.
+type Signer interface {
+	Backend() loader.Backend
+	Address() string
+	Sign(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+type EvmSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewEvmSigner(key *ecdsa.PrivateKey) *EvmSigner {
+	return &EvmSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func NewEvmSignerFromHex(hexKey string) (*EvmSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return NewEvmSigner(key), nil
+}
+
+func (s *EvmSigner) Backend() loader.Backend {
+	return loader.EthereumBackend
+}
+
+func (s *EvmSigner) Address() string {
+	return s.address.Hex()
+}
+
+func (s *EvmSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	if len(payload) != 32 {
+		return nil, fmt.Errorf("evm payload must be a 32 bytes hash, got %d", len(payload))
+	}
+	return crypto.Sign(payload, s.key)
+}
+
+type SolanaSigner struct {
+	key solana.PrivateKey
+}
+
+func NewSolanaSigner(key solana.PrivateKey) *SolanaSigner {
+	return &SolanaSigner{key: key}
+}
+
+func NewSolanaSignerFromBase58(base58Key string) (*SolanaSigner, error) {
+	key, err := solana.PrivateKeyFromBase58(strings.TrimSpace(base58Key))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid solana private key length %d", len(key))
+	}
+	return NewSolanaSigner(key), nil
+}
+
+func (s *SolanaSigner) Backend() loader.Backend {
+	return loader.SolanaBackend
+}
+
+func (s *SolanaSigner) Address() string {
+	return s.key.PublicKey().String()
+}
+
+func (s *SolanaSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s.key), payload), nil
+}
+
+// StarknetSigner signs for an account contract, whose address is not derived from the key
+type StarknetSigner struct {
+	key     *big.Int
+	address string
+}
+
+func NewStarknetSigner(hexKey string, accountAddr string) (*StarknetSigner, error) {
+	key, ok := new(big.Int).SetString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid starknet private key")
+	}
+	if _, _, err := curve.Curve.PrivateToPoint(key); err != nil {
+		return nil, err
+	}
+	return &StarknetSigner{key: key, address: strings.TrimSpace(accountAddr)}, nil
+}
+
+func (s *StarknetSigner) Backend() loader.Backend {
+	return loader.StarknetBackend
+}
+
+func (s *StarknetSigner) Address() string {
+	return s.address
+}
+
+// PublicKey is the x coordinate of the public key, the one account contracts store
+func (s *StarknetSigner) PublicKey() (*big.Int, error) {
+	x, _, err := curve.Curve.PrivateToPoint(s.key)
+	return x, err
+}
+
+func (s *StarknetSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	if len(payload) != 32 {
+		return nil, fmt.Errorf("starknet payload must be a 32 bytes hash, got %d", len(payload))
+	}
+	r, sig, err := curve.Curve.Sign(new(big.Int).SetBytes(payload), s.key)
+	if err != nil {
+		return nil, err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signature, nil
+}
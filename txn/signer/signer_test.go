@@ -0,0 +1,121 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/ethereum/go-ethereum/core/types"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc/rpctest"
+	"github.com/owlto-dao/utils-go/txn/evm"
+	sol "github.com/owlto-dao/utils-go/txn/solana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignEvmBody(t *testing.T) {
+	ctx := context.TODO()
+	sim, err := rpctest.NewSimulatedEvm(2)
+	require.NoError(t, err)
+	defer sim.Close()
+
+	local := NewEvmSigner(sim.Accounts[0])
+	server := httptest.NewServer(SignHandler(local))
+	defer server.Close()
+	remote := NewRemoteSigner(server.URL, loader.EthereumBackend, local.Address())
+
+	estimator, err := evm.NewFeeEstimator(sim.ChainInfo)
+	require.NoError(t, err)
+	fees, err := estimator.SuggestFees(ctx)
+	require.NoError(t, err)
+	gasPrice, err := sim.Client.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+	legacyFee := &evm.FeeEstimate{GasLimit: 21000, GasPrice: gasPrice}
+	for nonce, signer := range []Signer{local, remote} {
+		fee := legacyFee
+		if nonce == 1 {
+			fee = fees
+			fee.GasLimit = 21000
+		}
+		body, err := evm.ToBodyWithFee(sim.Address(1).Hex(), big.NewInt(1e18), nil, fee)
+		require.NoError(t, err)
+		body, err = evm.WithNonce(body, uint64(nonce))
+		require.NoError(t, err)
+
+		raw, hash, err := SignEvmBody(ctx, signer, sim.ChainInfo, body)
+		require.NoError(t, err)
+		var tx types.Transaction
+		require.NoError(t, tx.UnmarshalBinary(raw))
+		assert.Equal(t, hash, tx.Hash().Hex())
+		require.NoError(t, sim.Client.SendTransaction(ctx, &tx))
+	}
+	_, err = sim.Commit()
+	require.NoError(t, err)
+	balance, err := sim.Rpc.GetBalance(ctx, sim.Address(1).Hex(), "0x0000000000000000000000000000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, "1002000000000000000000", balance.String())
+
+	_, err = NewRemoteSigner(server.URL, loader.EthereumBackend, sim.Address(1).Hex()).Sign(ctx, make([]byte, 32))
+	assert.ErrorContains(t, err, "unknown signer")
+}
+
+func TestSignSolanaBody(t *testing.T) {
+	ctx := context.TODO()
+	payer := NewSolanaSigner(solana.NewWallet().PrivateKey)
+	newAccount := solana.NewWallet()
+	server := httptest.NewServer(SignHandler(payer))
+	defer server.Close()
+
+	payerpk := solana.MustPublicKeyFromBase58(payer.Address())
+	inst := system.NewCreateAccountInstruction(1e6, 0, solana.SystemProgramID, payerpk, newAccount.PublicKey()).Build()
+	body, err := sol.ToBody([]solana.Instruction{inst}, []sol.SolanaKeypair{{PublicKey: newAccount.PublicKey(), PrivateKey: newAccount.PrivateKey}})
+	require.NoError(t, err)
+
+	remote := NewRemoteSigner(server.URL, loader.SolanaBackend, payer.Address())
+	raw, hash, err := SignSolanaBody(ctx, remote, body, solana.Hash{1})
+	require.NoError(t, err)
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(raw))
+	require.NoError(t, err)
+	assert.Len(t, tx.Signatures, 2)
+	assert.Equal(t, hash, tx.Signatures[0].String())
+	assert.NoError(t, tx.VerifySignatures())
+
+	_, _, err = SignSolanaBody(ctx, payer, body[:len(body)-1], solana.Hash{1})
+	assert.Error(t, err)
+
+	_, _, err = SignSolanaBody(ctx, truncatingSigner{payer}, body, solana.Hash{1})
+	assert.ErrorContains(t, err, "must be 64 bytes")
+}
+
+// truncatingSigner stands for a remote signer returning a malformed signature
+type truncatingSigner struct {
+	Signer
+}
+
+func (s truncatingSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	signature, err := s.Signer.Sign(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return signature[:63], nil
+}
+
+func TestSignStarknetHash(t *testing.T) {
+	signer, err := NewStarknetSigner("0x1234567890abcdef", "0x1")
+	require.NoError(t, err)
+	hash := new(felt.Felt).SetUint64(42)
+	signature, err := SignStarknetHash(context.TODO(), signer, hash)
+	require.NoError(t, err)
+	require.Len(t, signature, 2)
+
+	x, y, err := curve.Curve.PrivateToPoint(new(big.Int).SetUint64(0x1234567890abcdef))
+	require.NoError(t, err)
+	assert.True(t, curve.Curve.Verify(big.NewInt(42), signature[0].BigInt(new(big.Int)), signature[1].BigInt(new(big.Int)), x, y))
+}
@@ -0,0 +1,122 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SolanaAccountReader is the part of rpc.SolanaRpc the builders need to look at the chain
+type SolanaAccountReader interface {
+	GetClient() *rpc.Client
+	GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error)
+}
+
+// NewCreateAtaIdempotentInstruction builds the associated token program CreateIdempotent
+// instruction, which succeeds without doing anything when the account already exists.
+func NewCreateAtaIdempotentInstruction(payer solana.PublicKey, owner solana.PublicKey, mint solana.PublicKey, tokenProgram solana.PublicKey) (solana.Instruction, error) {
+	ata, err := GetAtaWithProgram(owner, mint, tokenProgram)
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewInstruction(
+		solana.SPLAssociatedTokenAccountProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(ata).WRITE(),
+			solana.Meta(owner),
+			solana.Meta(mint),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(tokenProgram),
+		},
+		[]byte{1},
+	), nil
+}
+
+// TokenAccountSize is the size of an associated token account of the mint. Token-2022
+// accounts carry the account extensions required by the mint extensions and ImmutableOwner.
+func TokenAccountSize(mint *MintInfo) uint64 {
+	if !mint.IsToken2022() {
+		return accountBaseSize
+	}
+	// the account type byte, then a 4 bytes TLV header per extension
+	size := uint64(accountBaseSize + 1 + 4)
+	if mint.Extensions != nil {
+		if mint.Extensions.TransferFeeConfig != nil {
+			size += 4 + 8
+		}
+		if mint.Extensions.NonTransferable {
+			size += 4
+		}
+		if mint.Extensions.TransferHook != nil {
+			size += 4 + 1
+		}
+	}
+	return size
+}
+
+// AtaExists tells whether the associated token account has been created
+func AtaExists(ctx context.Context, reader SolanaAccountReader, ata solana.PublicKey) (bool, error) {
+	rsp, err := reader.GetAccountInfo(ctx, ata)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return rsp != nil && rsp.Value != nil, nil
+}
+
+// SplTransferBodyWithAta is SplTransferBodyWithMint creating the receiver ata first when it
+// does not exist, paid by the sender. It also returns the rent in lamports the creation
+// costs the sender, 0 when the ata exists. A nil reader skips the check and never creates.
+func SplTransferBodyWithAta(ctx context.Context, reader SolanaAccountReader, senderAddr string, receiverAddr string, amount *big.Int, mint *MintInfo) ([]byte, uint64, error) {
+	insts, err := SplTransferInstructions(senderAddr, receiverAddr, amount, mint)
+	if err != nil {
+		return nil, 0, err
+	}
+	if reader == nil {
+		body, err := ToBody(insts, nil)
+		return body, 0, err
+	}
+
+	senderpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(senderAddr))
+	if err != nil {
+		return nil, 0, err
+	}
+	receiverpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(receiverAddr))
+	if err != nil {
+		return nil, 0, err
+	}
+	receiverAta, err := GetAtaWithProgram(receiverpk, mint.Mint, mint.ProgramId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rent uint64
+	exists, err := AtaExists(ctx, reader, receiverAta)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		rent, err = reader.GetClient().GetMinimumBalanceForRentExemption(ctx, TokenAccountSize(mint), rpc.CommitmentConfirmed)
+		if err != nil {
+			return nil, 0, err
+		}
+		create, err := NewCreateAtaIdempotentInstruction(senderpk, receiverpk, mint.Mint, mint.ProgramId)
+		if err != nil {
+			return nil, 0, err
+		}
+		insts = append([]solana.Instruction{create}, insts...)
+	}
+
+	body, err := ToBody(insts, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, rent, nil
+}
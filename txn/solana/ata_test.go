@@ -0,0 +1,60 @@
+package sol
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubReader struct {
+	client *rpc.Client
+}
+
+func (r *stubReader) GetClient() *rpc.Client {
+	return r.client
+}
+
+func (r *stubReader) GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	return r.client.GetAccountInfo(ctx, account)
+}
+
+func TestSplTransferBodyWithAta(t *testing.T) {
+	ctx := context.TODO()
+	sender, receiver, created := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	mint := NewClassicMintInfo(solana.NewWallet().PublicKey(), 6)
+	createdAta, err := GetAtaWithProgram(created, mint.Mint, mint.ProgramId)
+	require.NoError(t, err)
+	reader := &stubReader{client: newStubNode(t, solana.Hash{1}, map[solana.PublicKey]interface{}{
+		createdAta: token.Account{Mint: mint.Mint, Owner: created, State: token.Initialized},
+	})}
+
+	body, rent, err := SplTransferBodyWithAta(ctx, reader, sender.String(), receiver.String(), big.NewInt(100), mint)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2039280), rent)
+	var solanaBody SolanaBody
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	require.Len(t, solanaBody.Instructions, 2)
+	create := solanaBody.Instructions[0]
+	assert.Equal(t, solana.SPLAssociatedTokenAccountProgramID, create.ProgramId)
+	assert.Equal(t, []byte{1}, []byte(create.Data))
+	assert.Equal(t, sender, create.Accounts[0].PublicKey)
+	assert.True(t, create.Accounts[0].IsSigner)
+	assert.Equal(t, solanaBody.Instructions[1].Accounts[2].PublicKey, create.Accounts[1].PublicKey)
+
+	body, rent, err = SplTransferBodyWithAta(ctx, reader, sender.String(), created.String(), big.NewInt(100), mint)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), rent)
+	solanaBody = SolanaBody{}
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	assert.Len(t, solanaBody.Instructions, 1)
+
+	token2022 := &MintInfo{Mint: mint.Mint, ProgramId: Token2022ProgramID, Extensions: &MintExtensions{TransferFeeConfig: &TransferFeeConfig{}}}
+	assert.Equal(t, uint64(182), TokenAccountSize(token2022))
+}
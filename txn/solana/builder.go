@@ -0,0 +1,231 @@
+package sol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	lookup "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MaxTxSize is the solana packet size limit a serialized signed tx must fit in
+const MaxTxSize = 1232
+
+// TxBuilder turns a SolanaBody into transactions paid by payer. The recent blockhash is
+// fetched at build time unless a durable nonce account is configured.
+type TxBuilder struct {
+	client         *rpc.Client
+	payer          solana.PublicKey
+	nonceAccount   *solana.PublicKey
+	nonceAuthority solana.PublicKey
+	commitment     rpc.CommitmentType
+	// AllowSplit lets Build spread instructions over several txs when they do not fit in one,
+	// the txs then land independently and are no longer atomic. It does not apply with a
+	// durable nonce, which only one tx can consume.
+	AllowSplit bool
+}
+
+func NewTxBuilder(client *rpc.Client, payer solana.PublicKey) *TxBuilder {
+	return &TxBuilder{client: client, payer: payer, commitment: rpc.CommitmentFinalized}
+}
+
+// WithDurableNonce makes the tx start with an advance nonce instruction signed by the authority.
+// The first tx landing advances the nonce, so Build fails instead of splitting a body.
+func (b *TxBuilder) WithDurableNonce(nonceAccount solana.PublicKey, authority solana.PublicKey) *TxBuilder {
+	b.nonceAccount = &nonceAccount
+	b.nonceAuthority = authority
+	return b
+}
+
+func (b *TxBuilder) WithCommitment(commitment rpc.CommitmentType) *TxBuilder {
+	b.commitment = commitment
+	return b
+}
+
+func (b *TxBuilder) BuildFromJson(ctx context.Context, body []byte) ([]*solana.Transaction, error) {
+	var solanaBody SolanaBody
+	if err := json.Unmarshal(body, &solanaBody); err != nil {
+		return nil, err
+	}
+	return b.Build(ctx, &solanaBody)
+}
+
+// Build returns one tx, or several in execution order when AllowSplit is set and the
+// instructions do not fit in one. The txs are signed by the body keypairs they need, the
+// payer and nonce authority signatures are left zero for the caller.
+func (b *TxBuilder) Build(ctx context.Context, body *SolanaBody) ([]*solana.Transaction, error) {
+	tables, err := b.resolveLookupTables(ctx, body.LookupTables)
+	if err != nil {
+		return nil, err
+	}
+	blockhash, err := b.getBlockhash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keypairs := make(map[solana.PublicKey]solana.PrivateKey, len(body.Keypairs))
+	for _, keypair := range body.Keypairs {
+		keypairs[keypair.PublicKey] = keypair.PrivateKey
+	}
+	build := func(insts []solana.Instruction) (*solana.Transaction, int, error) {
+		tx, err := b.newTransaction(insts, blockhash, tables)
+		if err != nil {
+			return nil, 0, err
+		}
+		size, err := TxSize(tx)
+		return tx, size, err
+	}
+
+	insts := body.ToInstructions()
+	tx, size, err := build(insts)
+	if err != nil {
+		return nil, err
+	}
+	var txs []*solana.Transaction
+	if size <= MaxTxSize {
+		txs = []*solana.Transaction{tx}
+	} else if !b.AllowSplit {
+		return nil, fmt.Errorf("tx size %d exceeds %d", size, MaxTxSize)
+	} else if b.nonceAccount != nil {
+		return nil, fmt.Errorf("tx size %d exceeds %d and split txs cannot share durable nonce %s", size, MaxTxSize, b.nonceAccount)
+	} else if txs, err = b.split(insts, build); err != nil {
+		return nil, err
+	}
+
+	for _, tx := range txs {
+		if err := PartialSign(tx, keypairs); err != nil {
+			return nil, err
+		}
+	}
+	return txs, nil
+}
+
+// split packs the instructions greedily in order, an instruction too large on its own fails
+func (b *TxBuilder) split(insts []solana.Instruction, build func([]solana.Instruction) (*solana.Transaction, int, error)) ([]*solana.Transaction, error) {
+	var txs []*solana.Transaction
+	var current *solana.Transaction
+	start := 0
+	for end := 1; end <= len(insts); end++ {
+		tx, size, err := build(insts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if size <= MaxTxSize {
+			current = tx
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("instruction %d alone exceeds %d bytes", end-1, MaxTxSize)
+		}
+		txs = append(txs, current)
+		current, start, end = nil, end-1, end-1
+	}
+	if current != nil {
+		txs = append(txs, current)
+	}
+	return txs, nil
+}
+
+func (b *TxBuilder) newTransaction(insts []solana.Instruction, blockhash solana.Hash, tables map[solana.PublicKey]solana.PublicKeySlice) (*solana.Transaction, error) {
+	if b.nonceAccount != nil {
+		advance := system.NewAdvanceNonceAccountInstruction(*b.nonceAccount, solana.SysVarRecentBlockHashesPubkey, b.nonceAuthority).Build()
+		insts = append([]solana.Instruction{advance}, insts...)
+	}
+	opts := []solana.TransactionOption{solana.TransactionPayer(b.payer)}
+	if len(tables) > 0 {
+		opts = append(opts, solana.TransactionAddressTables(tables))
+	}
+	return solana.NewTransaction(insts, blockhash, opts...)
+}
+
+func (b *TxBuilder) getBlockhash(ctx context.Context) (solana.Hash, error) {
+	if b.nonceAccount == nil {
+		rsp, err := b.client.GetLatestBlockhash(ctx, b.commitment)
+		if err != nil {
+			return solana.Hash{}, err
+		}
+		return rsp.Value.Blockhash, nil
+	}
+
+	account, err := b.client.GetAccountInfoWithOpts(ctx, *b.nonceAccount, &rpc.GetAccountInfoOpts{Commitment: b.commitment})
+	if err != nil {
+		return solana.Hash{}, fmt.Errorf("get nonce account %s error %w", b.nonceAccount, err)
+	}
+	var nonce system.NonceAccount
+	if err := bin.NewBinDecoder(account.GetBinary()).Decode(&nonce); err != nil {
+		return solana.Hash{}, fmt.Errorf("decode nonce account %s error %w", b.nonceAccount, err)
+	}
+	if nonce.AuthorizedPubkey != b.nonceAuthority {
+		return solana.Hash{}, fmt.Errorf("nonce account %s authority is %s", b.nonceAccount, nonce.AuthorizedPubkey)
+	}
+	return solana.Hash(nonce.Nonce), nil
+}
+
+// resolveLookupTables fetches the tables given without their addresses
+func (b *TxBuilder) resolveLookupTables(ctx context.Context, tables map[solana.PublicKey]solana.PublicKeySlice) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	resolved := make(map[solana.PublicKey]solana.PublicKeySlice, len(tables))
+	for table, addresses := range tables {
+		if len(addresses) == 0 {
+			state, err := lookup.GetAddressLookupTable(ctx, b.client, table)
+			if err != nil {
+				return nil, fmt.Errorf("get lookup table %s error %w", table, err)
+			}
+			addresses = state.Addresses
+		}
+		resolved[table] = addresses
+	}
+	return resolved, nil
+}
+
+// TxSize is the serialized size of the tx once every required signature is set
+func TxSize(tx *solana.Transaction) (int, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	signatures := int(tx.Message.Header.NumRequiredSignatures)
+	// the signature count is a compact-u16, one byte below 128
+	return len(message) + 1 + signatures*64, nil
+}
+
+// PartialSign fills the signature slots of the given keys, the other slots stay zero
+func PartialSign(tx *solana.Transaction, keys map[solana.PublicKey]solana.PrivateKey) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	signers := tx.Message.Signers()
+	if len(tx.Signatures) != len(signers) {
+		tx.Signatures = make([]solana.Signature, len(signers))
+	}
+	for i, signer := range signers {
+		key, ok := keys[signer]
+		if !ok {
+			continue
+		}
+		if tx.Signatures[i], err = key.Sign(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSignature fills the signature slot of signer, typically the payer's from a Signer
+func SetSignature(tx *solana.Transaction, signer solana.PublicKey, signature solana.Signature) error {
+	for i, key := range tx.Message.Signers() {
+		if key == signer {
+			if len(tx.Signatures) <= i {
+				signatures := make([]solana.Signature, len(tx.Message.Signers()))
+				copy(signatures, tx.Signatures)
+				tx.Signatures = signatures
+			}
+			tx.Signatures[i] = signature
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a signer of the tx", signer)
+}
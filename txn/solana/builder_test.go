@@ -0,0 +1,160 @@
+package sol
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	lookup "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubNode answers getLatestBlockhash and getAccountInfo from the given accounts, the
+// rent with the mainnet formula, simulateTransaction with stubUnitsConsumed and getRecentPrioritizationFees with stubFees
+var (
+	stubUnitsConsumed uint64 = 30_000
+	stubFees                 = []uint64{0, 400, 100, 300, 200}
+)
+
+func newStubNode(t *testing.T, blockhash solana.Hash, accounts map[solana.PublicKey]interface{}) *rpc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		switch req.Method {
+		case "getLatestBlockhash":
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value":   map[string]interface{}{"blockhash": blockhash.String(), "lastValidBlockHeight": 100},
+			}
+		case "getAccountInfo":
+			account, ok := accounts[solana.MustPublicKeyFromBase58(req.Params[0].(string))]
+			if !ok {
+				result = map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": nil}
+				break
+			}
+			data, err := bin.MarshalBin(account)
+			require.NoError(t, err)
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value": map[string]interface{}{
+					"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+					"executable": false,
+					"lamports":   1,
+					"owner":      solana.SystemProgramID.String(),
+					"rentEpoch":  0,
+				},
+			}
+		case "getMinimumBalanceForRentExemption":
+			result = 890880 + 6960*uint64(req.Params[0].(float64))
+		case "simulateTransaction":
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value":   map[string]interface{}{"err": nil, "logs": []string{}, "unitsConsumed": stubUnitsConsumed},
+			}
+		case "getRecentPrioritizationFees":
+			fees := make([]map[string]interface{}, 0, len(stubFees))
+			for i, fee := range stubFees {
+				fees = append(fees, map[string]interface{}{"slot": i + 1, "prioritizationFee": fee})
+			}
+			result = fees
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result})
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+func TestTxBuilder(t *testing.T) {
+	ctx := context.TODO()
+	payer := solana.NewWallet()
+	newAccount := solana.NewWallet()
+	nonceAccount, table := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	blockhash, nonceHash := solana.Hash{1}, solana.Hash{2}
+
+	receivers := make(solana.PublicKeySlice, 40)
+	for i := range receivers {
+		receivers[i] = solana.NewWallet().PublicKey()
+	}
+	client := newStubNode(t, blockhash, map[solana.PublicKey]interface{}{
+		nonceAccount: system.NonceAccount{State: 1, AuthorizedPubkey: payer.PublicKey(), Nonce: solana.PublicKey(nonceHash)},
+		table:        lookup.AddressLookupTableState{TypeIndex: 1, DeactivationSlot: ^uint64(0), Addresses: receivers},
+	})
+
+	inst := system.NewCreateAccountInstruction(1e6, 0, solana.SystemProgramID, payer.PublicKey(), newAccount.PublicKey()).Build()
+	body, err := ToBody([]solana.Instruction{inst}, []SolanaKeypair{{PublicKey: newAccount.PublicKey(), PrivateKey: newAccount.PrivateKey}})
+	require.NoError(t, err)
+
+	builder := NewTxBuilder(client, payer.PublicKey())
+	txs, err := builder.BuildFromJson(ctx, body)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	tx := txs[0]
+	assert.Equal(t, blockhash, tx.Message.RecentBlockhash)
+	assert.Equal(t, solana.Signature{}, tx.Signatures[0])
+	assert.NotEqual(t, solana.Signature{}, tx.Signatures[1])
+	message, err := tx.Message.MarshalBinary()
+	require.NoError(t, err)
+	signature, err := payer.PrivateKey.Sign(message)
+	require.NoError(t, err)
+	require.NoError(t, SetSignature(tx, payer.PublicKey(), signature))
+	assert.NoError(t, tx.VerifySignatures())
+
+	txs, err = NewTxBuilder(client, payer.PublicKey()).WithDurableNonce(nonceAccount, payer.PublicKey()).BuildFromJson(ctx, body)
+	require.NoError(t, err)
+	assert.Equal(t, nonceHash, txs[0].Message.RecentBlockhash)
+	assert.Equal(t, solana.SystemProgramID, txs[0].Message.AccountKeys[txs[0].Message.Instructions[0].ProgramIDIndex])
+
+	insts := make([]solana.Instruction, 0, len(receivers))
+	for _, receiver := range receivers {
+		insts = append(insts, system.NewTransferInstruction(1, payer.PublicKey(), receiver).Build())
+	}
+	transfers, err := ToSolanaBody(insts, nil, nil)
+	require.NoError(t, err)
+	_, err = builder.Build(ctx, transfers)
+	assert.ErrorContains(t, err, "exceeds")
+
+	builder.AllowSplit = true
+	txs, err = builder.Build(ctx, transfers)
+	require.NoError(t, err)
+	assert.Greater(t, len(txs), 1)
+	count := 0
+	for _, tx := range txs {
+		size, err := TxSize(tx)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, size, MaxTxSize)
+		count += len(tx.Message.Instructions)
+	}
+	assert.Equal(t, len(receivers), count)
+
+	// only the first split tx could consume the nonce, the others would never land
+	nonceBuilder := NewTxBuilder(client, payer.PublicKey()).WithDurableNonce(nonceAccount, payer.PublicKey())
+	nonceBuilder.AllowSplit = true
+	_, err = nonceBuilder.Build(ctx, transfers)
+	assert.ErrorContains(t, err, "durable nonce")
+	txs, err = nonceBuilder.BuildFromJson(ctx, body)
+	require.NoError(t, err)
+	assert.Len(t, txs, 1)
+
+	// the lookup table compresses the receivers into a single v0 tx
+	transfers.LookupTables = map[solana.PublicKey]solana.PublicKeySlice{table: nil}
+	txs, err = builder.Build(ctx, transfers)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.True(t, txs[0].Message.IsVersioned())
+	raw, err := txs[0].MarshalBinary()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(raw), MaxTxSize)
+}
@@ -92,6 +92,22 @@ func GetAtaFromPk(pk solana.PublicKey, mintpk solana.PublicKey) (solana.PublicKe
 
 }
 
+// GetAtaWithProgram derives the associated token account for a mint owned by tokenProgram.
+func GetAtaWithProgram(pk solana.PublicKey, mintpk solana.PublicKey, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	ata, _, err := solana.FindProgramAddress([][]byte{
+		pk[:],
+		tokenProgram[:],
+		mintpk[:],
+	},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	return ata, nil
+}
+
 func TransferBody(senderAddr string, receiverAddr string, amount *big.Int) ([]byte, error) {
 	senderAddr = strings.TrimSpace(senderAddr)
 	receiverAddr = strings.TrimSpace(receiverAddr)
@@ -143,3 +159,16 @@ func ToSolanaBody(insts []solana.Instruction, keypairs []SolanaKeypair, lookupTa
 	return &body, nil
 
 }
+
+// ToInstructions turns the body back into instructions, the inverse of AddInstructions
+func (body *SolanaBody) ToInstructions() []solana.Instruction {
+	insts := make([]solana.Instruction, 0, len(body.Instructions))
+	for _, minst := range body.Instructions {
+		accounts := make(solana.AccountMetaSlice, 0, len(minst.Accounts))
+		for _, acc := range minst.Accounts {
+			accounts = append(accounts, solana.NewAccountMeta(acc.PublicKey, acc.IsWritable, acc.IsSigner))
+		}
+		insts = append(insts, solana.NewInstruction(minst.ProgramId, accounts, minst.Data))
+	}
+	return insts
+}
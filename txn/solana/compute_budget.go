@@ -0,0 +1,247 @@
+package sol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+const (
+	// MaxComputeUnitLimit is the most compute units a tx can request
+	MaxComputeUnitLimit = 1_400_000
+	// DefaultInstructionUnits is what the runtime grants each instruction without a limit instruction
+	DefaultInstructionUnits = 200_000
+	// maxFeeAccounts is the most accounts getRecentPrioritizationFees accepts
+	maxFeeAccounts = 128
+)
+
+// ComputeBudgetConfig tunes the compute budget of a chain, zero values fall back to the defaults.
+// The unit price is clamped to the price caps, then lowered so that the priority fee stays
+// under MaxPriorityFee.
+type ComputeBudgetConfig struct {
+	// Simulate sizes the unit limit from a simulation, otherwise DefaultUnitLimit is used
+	Simulate bool `mapstructure:"simulate"`
+	// UnitLimitMultiplier scales the simulated units, defaults to 1.2
+	UnitLimitMultiplier float64 `mapstructure:"unit_limit_multiplier"`
+	// DefaultUnitLimit defaults to DefaultInstructionUnits per instruction
+	DefaultUnitLimit uint32 `mapstructure:"default_unit_limit"`
+	// FeePercentile is the getRecentPrioritizationFees percentile used for the price, defaults to 75
+	FeePercentile float64 `mapstructure:"fee_percentile"`
+	// prices are in micro-lamports per unit, 0 means no cap
+	MinUnitPrice uint64 `mapstructure:"min_unit_price"`
+	MaxUnitPrice uint64 `mapstructure:"max_unit_price"`
+	// MaxPriorityFee is in lamports, 0 means no cap
+	MaxPriorityFee uint64 `mapstructure:"max_priority_fee"`
+}
+
+func (cfg ComputeBudgetConfig) withDefaults() ComputeBudgetConfig {
+	if cfg.UnitLimitMultiplier <= 0 {
+		cfg.UnitLimitMultiplier = 1.2
+	}
+	if cfg.FeePercentile <= 0 || cfg.FeePercentile > 100 {
+		cfg.FeePercentile = 75
+	}
+	return cfg
+}
+
+var computeBudgetConfigs = loader.NewChainConfigs[ComputeBudgetConfig]()
+
+func SetComputeBudgetConfig(chainName string, cfg ComputeBudgetConfig) {
+	computeBudgetConfigs.Set(chainName, cfg)
+}
+
+func GetComputeBudgetConfig(chainName string) ComputeBudgetConfig {
+	return computeBudgetConfigs.Get(chainName)
+}
+
+// ComputeBudget is the unit limit and the unit price in micro-lamports of a tx
+type ComputeBudget struct {
+	UnitLimit uint32
+	UnitPrice uint64
+}
+
+// PriorityFee is the lamports paid on top of the signature fees, rounded up like the runtime
+func (budget *ComputeBudget) PriorityFee() uint64 {
+	return priorityFee(budget.UnitLimit, budget.UnitPrice)
+}
+
+func (budget *ComputeBudget) Instructions() []solana.Instruction {
+	insts := []solana.Instruction{computebudget.NewSetComputeUnitLimitInstruction(budget.UnitLimit).Build()}
+	if budget.UnitPrice > 0 {
+		insts = append(insts, computebudget.NewSetComputeUnitPriceInstruction(budget.UnitPrice).Build())
+	}
+	return insts
+}
+
+func priorityFee(limit uint32, price uint64) uint64 {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(uint64(limit)), new(big.Int).SetUint64(price))
+	fee.Add(fee, big.NewInt(999_999))
+	return fee.Div(fee, big.NewInt(1_000_000)).Uint64()
+}
+
+type ComputeBudgetEstimator struct {
+	client *rpc.Client
+	cfg    ComputeBudgetConfig
+}
+
+// NewComputeBudgetEstimator uses the config registered by SetComputeBudgetConfig for the chain
+func NewComputeBudgetEstimator(client *rpc.Client, chainName string) *ComputeBudgetEstimator {
+	return NewComputeBudgetEstimatorWithConfig(client, GetComputeBudgetConfig(chainName))
+}
+
+func NewComputeBudgetEstimatorWithConfig(client *rpc.Client, cfg ComputeBudgetConfig) *ComputeBudgetEstimator {
+	return &ComputeBudgetEstimator{client: client, cfg: cfg.withDefaults()}
+}
+
+// Estimate sizes the budget of the body paid by payer, compute budget instructions already
+// in the body are ignored.
+func (e *ComputeBudgetEstimator) Estimate(ctx context.Context, payer solana.PublicKey, body *SolanaBody) (*ComputeBudget, error) {
+	insts := withoutComputeBudget(body.ToInstructions())
+
+	var limit uint32
+	if e.cfg.Simulate {
+		units, err := e.simulate(ctx, payer, insts, body.LookupTables)
+		if err != nil {
+			return nil, err
+		}
+		limit = uint32(math.Min(math.Ceil(float64(units)*e.cfg.UnitLimitMultiplier), MaxComputeUnitLimit))
+	} else if e.cfg.DefaultUnitLimit > 0 {
+		limit = e.cfg.DefaultUnitLimit
+	} else {
+		limit = uint32(math.Min(float64(DefaultInstructionUnits*len(insts)), MaxComputeUnitLimit))
+	}
+
+	price, err := e.unitPrice(ctx, writableAccounts(payer, insts))
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.MaxPriorityFee > 0 && priorityFee(limit, price) > e.cfg.MaxPriorityFee {
+		capped := new(big.Int).Mul(new(big.Int).SetUint64(e.cfg.MaxPriorityFee), big.NewInt(1_000_000))
+		capped.Div(capped, big.NewInt(int64(limit)))
+		log.Warnf("solana unit price %d capped to %d by max priority fee %d", price, capped.Uint64(), e.cfg.MaxPriorityFee)
+		price = capped.Uint64()
+	}
+	return &ComputeBudget{UnitLimit: limit, UnitPrice: price}, nil
+}
+
+// simulate runs the instructions under the max limit, the blockhash is replaced by the node
+// and the signatures are not verified
+func (e *ComputeBudgetEstimator) simulate(ctx context.Context, payer solana.PublicKey, insts []solana.Instruction, tables map[solana.PublicKey]solana.PublicKeySlice) (uint64, error) {
+	builder := NewTxBuilder(e.client, payer)
+	resolved, err := builder.resolveLookupTables(ctx, tables)
+	if err != nil {
+		return 0, err
+	}
+	insts = append([]solana.Instruction{computebudget.NewSetComputeUnitLimitInstruction(MaxComputeUnitLimit).Build()}, insts...)
+	tx, err := builder.newTransaction(insts, solana.Hash{}, resolved)
+	if err != nil {
+		return 0, err
+	}
+	if err := PartialSign(tx, nil); err != nil {
+		return 0, err
+	}
+
+	rsp, err := e.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment:             rpc.CommitmentConfirmed,
+		ReplaceRecentBlockhash: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("simulate error %w", err)
+	}
+	if rsp.Value.Err != nil {
+		errJson, _ := json.Marshal(rsp.Value.Err)
+		return 0, fmt.Errorf("simulate failed: %s logs: %v", errJson, rsp.Value.Logs)
+	}
+	if rsp.Value.UnitsConsumed == nil {
+		return 0, fmt.Errorf("simulate returned no units consumed")
+	}
+	return *rsp.Value.UnitsConsumed, nil
+}
+
+// unitPrice is the configured percentile of the recent fees paid to write the accounts
+func (e *ComputeBudgetEstimator) unitPrice(ctx context.Context, accounts solana.PublicKeySlice) (uint64, error) {
+	results, err := e.client.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		return 0, fmt.Errorf("get recent prioritization fees error %w", err)
+	}
+
+	var price uint64
+	if len(results) > 0 {
+		fees := make([]uint64, 0, len(results))
+		for _, result := range results {
+			fees = append(fees, result.PrioritizationFee)
+		}
+		sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+		index := int(math.Ceil(e.cfg.FeePercentile/100*float64(len(fees)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		price = fees[index]
+	}
+	if price < e.cfg.MinUnitPrice {
+		price = e.cfg.MinUnitPrice
+	}
+	if e.cfg.MaxUnitPrice > 0 && price > e.cfg.MaxUnitPrice {
+		price = e.cfg.MaxUnitPrice
+	}
+	return price, nil
+}
+
+// WithComputeBudget prepends the budget instructions to a body returned by the builders of
+// this package, replacing any it already has.
+func WithComputeBudget(body []byte, budget *ComputeBudget) ([]byte, error) {
+	var solanaBody SolanaBody
+	if err := json.Unmarshal(body, &solanaBody); err != nil {
+		return nil, err
+	}
+	insts := append(budget.Instructions(), withoutComputeBudget(solanaBody.ToInstructions())...)
+	solanaBody.Instructions = nil
+	if err := solanaBody.AddInstructions(insts); err != nil {
+		return nil, err
+	}
+	return json.Marshal(solanaBody)
+}
+
+// ApplyComputeBudget estimates the budget of a body and prepends its instructions
+func (e *ComputeBudgetEstimator) ApplyComputeBudget(ctx context.Context, payer solana.PublicKey, body []byte) ([]byte, error) {
+	var solanaBody SolanaBody
+	if err := json.Unmarshal(body, &solanaBody); err != nil {
+		return nil, err
+	}
+	budget, err := e.Estimate(ctx, payer, &solanaBody)
+	if err != nil {
+		return nil, err
+	}
+	return WithComputeBudget(body, budget)
+}
+
+func withoutComputeBudget(insts []solana.Instruction) []solana.Instruction {
+	result := make([]solana.Instruction, 0, len(insts))
+	for _, inst := range insts {
+		if !inst.ProgramID().Equals(solana.ComputeBudget) {
+			result = append(result, inst)
+		}
+	}
+	return result
+}
+
+func writableAccounts(payer solana.PublicKey, insts []solana.Instruction) solana.PublicKeySlice {
+	accounts := solana.PublicKeySlice{payer}
+	for _, inst := range insts {
+		for _, account := range inst.Accounts() {
+			if account.IsWritable && !accounts.Contains(account.PublicKey) && len(accounts) < maxFeeAccounts {
+				accounts = append(accounts, account.PublicKey)
+			}
+		}
+	}
+	return accounts
+}
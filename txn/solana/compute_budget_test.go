@@ -0,0 +1,56 @@
+package sol
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeBudget(t *testing.T) {
+	ctx := context.TODO()
+	sender, receiver := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	client := newStubNode(t, solana.Hash{1}, nil)
+
+	body, err := TransferBody(sender.String(), receiver.String(), big.NewInt(1000))
+	require.NoError(t, err)
+
+	estimator := NewComputeBudgetEstimatorWithConfig(client, ComputeBudgetConfig{Simulate: true})
+	body, err = estimator.ApplyComputeBudget(ctx, sender, body)
+	require.NoError(t, err)
+
+	var solanaBody SolanaBody
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	insts := solanaBody.ToInstructions()
+	require.Len(t, insts, 3)
+	limit, err := computebudget.DecodeInstruction(nil, insts[0].(*solana.GenericInstruction).DataBytes)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(36_000), limit.Impl.(*computebudget.SetComputeUnitLimit).Units)
+	price, err := computebudget.DecodeInstruction(nil, insts[1].(*solana.GenericInstruction).DataBytes)
+	require.NoError(t, err)
+	// the 75th percentile of the 5 stub fees
+	assert.Equal(t, uint64(300), price.Impl.(*computebudget.SetComputeUnitPrice).MicroLamports)
+	assert.Equal(t, solana.SystemProgramID, insts[2].ProgramID())
+
+	// without simulation the limit is the runtime default and the price is lowered to the fee cap
+	SetComputeBudgetConfig("Solana", ComputeBudgetConfig{MinUnitPrice: 1000, MaxPriorityFee: 100})
+	t.Cleanup(func() { computeBudgetConfigs.Delete("Solana") })
+	estimator = NewComputeBudgetEstimator(client, "solana")
+	budget, err := estimator.Estimate(ctx, sender, &solanaBody)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(DefaultInstructionUnits), budget.UnitLimit)
+	assert.Equal(t, uint64(500), budget.UnitPrice)
+	assert.Equal(t, uint64(100), budget.PriorityFee())
+
+	// the budget instructions already in the body are replaced
+	body, err = WithComputeBudget(body, budget)
+	require.NoError(t, err)
+	solanaBody = SolanaBody{}
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	assert.Len(t, solanaBody.Instructions, 3)
+}
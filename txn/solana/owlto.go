@@ -0,0 +1,144 @@
+package sol
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/owlto-dao/utils-go/abi/owlto_sol_transfer"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+// GetOwltoProgramId is the owlto transfer program of the chain, configured as its transfer contract
+func GetOwltoProgramId(chainInfo *loader.ChainInfo) (solana.PublicKey, error) {
+	if !chainInfo.TransferContractAddress.Valid || chainInfo.TransferContractAddress.String == "" {
+		return solana.PublicKey{}, fmt.Errorf("%v has no transfer contract", chainInfo.Name)
+	}
+	programId, err := solana.PublicKeyFromBase58(strings.TrimSpace(chainInfo.TransferContractAddress.String))
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("%v invalid transfer contract %v: %w", chainInfo.Name, chainInfo.TransferContractAddress.String, err)
+	}
+	return programId, nil
+}
+
+// OwltoTransferBody sends lamports through the owlto program, which records targetAddr
+// as the destination of the cross chain transfer.
+func OwltoTransferBody(chainInfo *loader.ChainInfo, senderAddr string, receiverAddr string, amount *big.Int, targetAddr string) ([]byte, error) {
+	insts, err := OwltoTransferInstructions(chainInfo, senderAddr, receiverAddr, amount, targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(insts, nil)
+}
+
+func OwltoTransferInstructions(chainInfo *loader.ChainInfo, senderAddr string, receiverAddr string, amount *big.Int, targetAddr string) ([]solana.Instruction, error) {
+	programId, err := GetOwltoProgramId(chainInfo)
+	if err != nil {
+		return nil, err
+	}
+	transferData, err := owltoTransferData(amount, targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	senderpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(senderAddr))
+	if err != nil {
+		return nil, err
+	}
+	receiverpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(receiverAddr))
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := owlto_sol_transfer.NewTransferLamportsInstruction(
+		transferData,
+		senderpk,
+		receiverpk,
+		solana.SystemProgramID,
+	).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	return owltoInstruction(inst, programId)
+}
+
+// OwltoSplTransferBody sends tokens of a mint owned by either token program through the owlto
+// program, between the associated token accounts of sender and receiver. The program passes
+// neither the mint nor the decimals, so token-2022 mints with a transfer fee or a transfer hook
+// are rejected.
+func OwltoSplTransferBody(chainInfo *loader.ChainInfo, senderAddr string, receiverAddr string, amount *big.Int, targetAddr string, mint *MintInfo) ([]byte, error) {
+	insts, err := OwltoSplTransferInstructions(chainInfo, senderAddr, receiverAddr, amount, targetAddr, mint)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(insts, nil)
+}
+
+func OwltoSplTransferInstructions(chainInfo *loader.ChainInfo, senderAddr string, receiverAddr string, amount *big.Int, targetAddr string, mint *MintInfo) ([]solana.Instruction, error) {
+	if mint.IsToken2022() && mint.Extensions != nil {
+		if mint.Extensions.TransferFeeConfig != nil {
+			return nil, fmt.Errorf("owlto transfer of %v unsupported: mint has a transfer fee", mint.Mint)
+		}
+		if mint.Extensions.TransferHook != nil {
+			return nil, fmt.Errorf("owlto transfer of %v unsupported: mint has a transfer hook", mint.Mint)
+		}
+	}
+	programId, err := GetOwltoProgramId(chainInfo)
+	if err != nil {
+		return nil, err
+	}
+	transferData, err := owltoTransferData(amount, targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	senderpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(senderAddr))
+	if err != nil {
+		return nil, err
+	}
+	receiverpk, err := solana.PublicKeyFromBase58(strings.TrimSpace(receiverAddr))
+	if err != nil {
+		return nil, err
+	}
+
+	senderAta, err := GetAtaWithProgram(senderpk, mint.Mint, mint.ProgramId)
+	if err != nil {
+		return nil, err
+	}
+	receiverAta, err := GetAtaWithProgram(receiverpk, mint.Mint, mint.ProgramId)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := owlto_sol_transfer.NewTransferSplTokensInstruction(
+		transferData,
+		senderpk,
+		senderAta,
+		receiverAta,
+		mint.ProgramId,
+	).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	return owltoInstruction(inst, programId)
+}
+
+func owltoTransferData(amount *big.Int, targetAddr string) (owlto_sol_transfer.TransferData, error) {
+	targetAddr = strings.TrimSpace(targetAddr)
+	if targetAddr == "" {
+		return owlto_sol_transfer.TransferData{}, fmt.Errorf("empty target address")
+	}
+	if amount.Sign() < 0 || !amount.IsUint64() {
+		return owlto_sol_transfer.TransferData{}, fmt.Errorf("invalid amount %v", amount)
+	}
+	return owlto_sol_transfer.TransferData{Amount: amount.Uint64(), TargetAddr: targetAddr}, nil
+}
+
+// owltoInstruction binds the generated instruction to the program of the chain instead of
+// the package wide owlto_sol_transfer.ProgramID
+func owltoInstruction(inst *owlto_sol_transfer.Instruction, programId solana.PublicKey) ([]solana.Instruction, error) {
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	return []solana.Instruction{solana.NewInstruction(programId, inst.Accounts(), data)}, nil
+}
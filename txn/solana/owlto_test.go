@@ -0,0 +1,61 @@
+package sol
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/owlto-dao/utils-go/abi/owlto_sol_transfer"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwltoTransferBody(t *testing.T) {
+	programId := solana.NewWallet().PublicKey()
+	sender, receiver := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	chainInfo := &loader.ChainInfo{Name: "SolanaMainnet", TransferContractAddress: sql.NullString{String: programId.String(), Valid: true}}
+	target := "0x5e809A85Aa182A9921EDD10a4163745bb3e36284"
+
+	body, err := OwltoTransferBody(chainInfo, sender.String(), receiver.String(), big.NewInt(1000), target)
+	require.NoError(t, err)
+	var solanaBody SolanaBody
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	require.Len(t, solanaBody.Instructions, 1)
+	inst := solanaBody.Instructions[0]
+	assert.Equal(t, programId, inst.ProgramId)
+	decoded, err := owlto_sol_transfer.DecodeInstruction(nil, inst.Data)
+	require.NoError(t, err)
+	transfer := decoded.Impl.(*owlto_sol_transfer.TransferLamports)
+	assert.Equal(t, uint64(1000), transfer.TransferData.Amount)
+	assert.Equal(t, target, transfer.TransferData.TargetAddr)
+	assert.Equal(t, sender, inst.Accounts[0].PublicKey)
+	assert.True(t, inst.Accounts[0].IsSigner)
+
+	mint := &MintInfo{Mint: solana.NewWallet().PublicKey(), ProgramId: Token2022ProgramID, Decimals: 6}
+	body, err = OwltoSplTransferBody(chainInfo, sender.String(), receiver.String(), big.NewInt(5), target, mint)
+	require.NoError(t, err)
+	solanaBody = SolanaBody{}
+	require.NoError(t, json.Unmarshal(body, &solanaBody))
+	inst = solanaBody.Instructions[0]
+	decoded, err = owlto_sol_transfer.DecodeInstruction(nil, inst.Data)
+	require.NoError(t, err)
+	assert.Equal(t, target, decoded.Impl.(*owlto_sol_transfer.TransferSplTokens).TransferData.TargetAddr)
+	receiverAta, err := GetAtaWithProgram(receiver, mint.Mint, mint.ProgramId)
+	require.NoError(t, err)
+	assert.Equal(t, receiverAta, inst.Accounts[2].PublicKey)
+	assert.Equal(t, Token2022ProgramID, inst.Accounts[3].PublicKey)
+
+	// the program cannot pay a transfer fee nor resolve the accounts of a transfer hook
+	mint.Extensions = &MintExtensions{TransferFeeConfig: &TransferFeeConfig{}}
+	_, err = OwltoSplTransferBody(chainInfo, sender.String(), receiver.String(), big.NewInt(5), target, mint)
+	assert.ErrorContains(t, err, "transfer fee")
+	mint.Extensions = &MintExtensions{TransferHook: &TransferHook{ProgramId: solana.NewWallet().PublicKey()}}
+	_, err = OwltoSplTransferBody(chainInfo, sender.String(), receiver.String(), big.NewInt(5), target, mint)
+	assert.ErrorContains(t, err, "transfer hook")
+
+	_, err = OwltoTransferBody(&loader.ChainInfo{Name: "SolanaMainnet"}, sender.String(), receiver.String(), big.NewInt(1), target)
+	assert.Error(t, err)
+}
@@ -8,16 +8,27 @@ import (
 	"github.com/gagliardetto/solana-go/programs/token"
 )
 
+// SplApproveBody builds an approve assuming the mint belongs to the classic token program.
+//
+// Deprecated: the body is invalid for token-2022 mints, use SplApproveBodyWithMint with the
+// mint of SolanaRpc.GetMintInfo instead.
 func SplApproveBody(senderAddr string, tokenAddr string, spenderAddr string, amount *big.Int, decimals int32) ([]byte, error) {
-	senderAddr = strings.TrimSpace(senderAddr)
 	tokenAddr = strings.TrimSpace(tokenAddr)
-	spenderAddr = strings.TrimSpace(spenderAddr)
 
-	senderpk, err := solana.PublicKeyFromBase58(senderAddr)
+	mintpk, err := solana.PublicKeyFromBase58(tokenAddr)
 	if err != nil {
 		return nil, err
 	}
-	mintpk, err := solana.PublicKeyFromBase58(tokenAddr)
+
+	return SplApproveBodyWithMint(senderAddr, spenderAddr, amount, NewClassicMintInfo(mintpk, uint8(decimals)))
+}
+
+// SplApproveBodyWithMint builds an approve for a mint owned by either token program, see SolanaRpc.GetMintInfo.
+func SplApproveBodyWithMint(senderAddr string, spenderAddr string, amount *big.Int, mint *MintInfo) ([]byte, error) {
+	senderAddr = strings.TrimSpace(senderAddr)
+	spenderAddr = strings.TrimSpace(spenderAddr)
+
+	senderpk, err := solana.PublicKeyFromBase58(senderAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -26,40 +37,63 @@ func SplApproveBody(senderAddr string, tokenAddr string, spenderAddr string, amo
 		return nil, err
 	}
 
-	senderAta, err := GetAtaFromPk(senderpk, mintpk)
+	senderAta, err := GetAtaWithProgram(senderpk, mint.Mint, mint.ProgramId)
 	if err != nil {
 		return nil, err
 	}
 
-	spenderAta, err := GetAtaFromPk(spenderpk, mintpk)
+	spenderAta, err := GetAtaWithProgram(spenderpk, mint.Mint, mint.ProgramId)
 	if err != nil {
 		return nil, err
 	}
 
-	inst := token.NewApproveCheckedInstruction(
+	inst, err := withProgramId(token.NewApproveCheckedInstruction(
 		amount.Uint64(),
-		uint8(decimals),
+		mint.Decimals,
 		senderAta,
-		mintpk,
+		mint.Mint,
 		spenderAta,
 		senderpk,
 		[]solana.PublicKey{},
-	).Build()
+	).Build(), mint.ProgramId)
+	if err != nil {
+		return nil, err
+	}
 
 	return ToBody([]solana.Instruction{inst}, nil)
 
 }
 
+// SqlTransferBody builds a transfer assuming the mint belongs to the classic token program.
+//
+// Deprecated: the body is invalid for token-2022 mints, use SplTransferBodyWithMint with the
+// mint of SolanaRpc.GetMintInfo instead.
 func SqlTransferBody(senderAddr string, tokenAddr string, receiverAddr string, amount *big.Int, decimals int32) ([]byte, error) {
-	senderAddr = strings.TrimSpace(senderAddr)
 	tokenAddr = strings.TrimSpace(tokenAddr)
-	receiverAddr = strings.TrimSpace(receiverAddr)
 
-	senderpk, err := solana.PublicKeyFromBase58(senderAddr)
+	mintpk, err := solana.PublicKeyFromBase58(tokenAddr)
 	if err != nil {
 		return nil, err
 	}
-	mintpk, err := solana.PublicKeyFromBase58(tokenAddr)
+
+	return SplTransferBodyWithMint(senderAddr, receiverAddr, amount, NewClassicMintInfo(mintpk, uint8(decimals)))
+}
+
+// SplTransferBodyWithMint builds a transfer for a mint owned by either token program. Mints with a
+// transfer fee use TransferCheckedWithFee so the transfer fails instead of withholding an unexpected fee.
+func SplTransferBodyWithMint(senderAddr string, receiverAddr string, amount *big.Int, mint *MintInfo) ([]byte, error) {
+	insts, err := SplTransferInstructions(senderAddr, receiverAddr, amount, mint)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(insts, nil)
+}
+
+func SplTransferInstructions(senderAddr string, receiverAddr string, amount *big.Int, mint *MintInfo) ([]solana.Instruction, error) {
+	senderAddr = strings.TrimSpace(senderAddr)
+	receiverAddr = strings.TrimSpace(receiverAddr)
+
+	senderpk, err := solana.PublicKeyFromBase58(senderAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -68,26 +102,41 @@ func SqlTransferBody(senderAddr string, tokenAddr string, receiverAddr string, a
 		return nil, err
 	}
 
-	senderAta, err := GetAtaFromPk(senderpk, mintpk)
+	senderAta, err := GetAtaWithProgram(senderpk, mint.Mint, mint.ProgramId)
 	if err != nil {
 		return nil, err
 	}
 
-	receiverAta, err := GetAtaFromPk(receiverpk, mintpk)
+	receiverAta, err := GetAtaWithProgram(receiverpk, mint.Mint, mint.ProgramId)
 	if err != nil {
 		return nil, err
 	}
 
-	inst := token.NewTransferCheckedInstruction(
+	if fee := mint.GetTransferFee(); fee != nil && mint.IsToken2022() {
+		inst := NewTransferCheckedWithFeeInstruction(
+			amount.Uint64(),
+			mint.Decimals,
+			fee.CalculateFee(amount.Uint64()),
+			senderAta,
+			mint.Mint,
+			receiverAta,
+			senderpk,
+		)
+		return []solana.Instruction{inst}, nil
+	}
+
+	inst, err := withProgramId(token.NewTransferCheckedInstruction(
 		amount.Uint64(),
-		uint8(decimals),
+		mint.Decimals,
 		senderAta,
-		mintpk,
+		mint.Mint,
 		receiverAta,
 		senderpk,
 		[]solana.PublicKey{},
-	).Build()
-
-	return ToBody([]solana.Instruction{inst}, nil)
+	).Build(), mint.ProgramId)
+	if err != nil {
+		return nil, err
+	}
 
+	return []solana.Instruction{inst}, nil
 }
@@ -0,0 +1,333 @@
+package sol
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var Token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
+// token-2022 pads mints to the size of a token account before the account type and the TLV entries
+const (
+	mintBaseSize          = 82
+	accountBaseSize       = 165
+	accountTypeMint uint8 = 1
+)
+
+type ExtensionType uint16
+
+const (
+	ExtensionUninitialized ExtensionType = iota
+	ExtensionTransferFeeConfig
+	ExtensionTransferFeeAmount
+	ExtensionMintCloseAuthority
+	ExtensionConfidentialTransferMint
+	ExtensionConfidentialTransferAccount
+	ExtensionDefaultAccountState
+	ExtensionImmutableOwner
+	ExtensionMemoTransfer
+	ExtensionNonTransferable
+	ExtensionInterestBearingConfig
+	ExtensionCpiGuard
+	ExtensionPermanentDelegate
+	ExtensionNonTransferableAccount
+	ExtensionTransferHook
+	ExtensionTransferHookAccount
+	ExtensionConfidentialTransferFeeConfig
+	ExtensionConfidentialTransferFeeAmount
+	ExtensionMetadataPointer
+	ExtensionTokenMetadata
+	ExtensionGroupPointer
+	ExtensionTokenGroup
+	ExtensionGroupMemberPointer
+	ExtensionTokenGroupMember
+)
+
+type TransferFee struct {
+	Epoch                  uint64
+	MaximumFee             uint64
+	TransferFeeBasisPoints uint16
+}
+
+// CalculateFee returns the fee withheld from a transfer of amount, rounded up and capped at MaximumFee.
+func (fee *TransferFee) CalculateFee(amount uint64) uint64 {
+	if fee.TransferFeeBasisPoints == 0 || amount == 0 {
+		return 0
+	}
+	raw := new(big.Int).Mul(new(big.Int).SetUint64(amount), big.NewInt(int64(fee.TransferFeeBasisPoints)))
+	raw.Add(raw, big.NewInt(9999))
+	raw.Div(raw, big.NewInt(10000))
+	if !raw.IsUint64() || raw.Uint64() > fee.MaximumFee {
+		return fee.MaximumFee
+	}
+	return raw.Uint64()
+}
+
+type TransferFeeConfig struct {
+	TransferFeeConfigAuthority solana.PublicKey
+	WithdrawWithheldAuthority  solana.PublicKey
+	WithheldAmount             uint64
+	OlderTransferFee           TransferFee
+	NewerTransferFee           TransferFee
+}
+
+func (cfg *TransferFeeConfig) GetEpochFee(epoch uint64) *TransferFee {
+	if epoch >= cfg.NewerTransferFee.Epoch {
+		return &cfg.NewerTransferFee
+	}
+	return &cfg.OlderTransferFee
+}
+
+type MetadataPointer struct {
+	Authority       solana.PublicKey
+	MetadataAddress solana.PublicKey
+}
+
+type InterestBearingConfig struct {
+	RateAuthority           solana.PublicKey
+	InitializationTimestamp int64
+	PreUpdateAverageRate    int16
+	LastUpdateTimestamp     int64
+	CurrentRate             int16
+}
+
+type TransferHook struct {
+	Authority solana.PublicKey
+	ProgramId solana.PublicKey
+}
+
+type TokenMetadata struct {
+	UpdateAuthority    solana.PublicKey
+	Mint               solana.PublicKey
+	Name               string
+	Symbol             string
+	Uri                string
+	AdditionalMetadata [][2]string
+}
+
+type MintExtensions struct {
+	TransferFeeConfig     *TransferFeeConfig
+	MintCloseAuthority    *solana.PublicKey
+	DefaultAccountState   *uint8
+	NonTransferable       bool
+	InterestBearingConfig *InterestBearingConfig
+	PermanentDelegate     *solana.PublicKey
+	TransferHook          *TransferHook
+	MetadataPointer       *MetadataPointer
+	TokenMetadata         *TokenMetadata
+	// Raw holds the value of every extension, including the ones not decoded above
+	Raw map[ExtensionType][]byte
+}
+
+// MintInfo describes a mint together with the token program that owns it.
+type MintInfo struct {
+	Mint       solana.PublicKey
+	ProgramId  solana.PublicKey
+	Decimals   uint8
+	Supply     uint64
+	Extensions *MintExtensions
+	// Epoch is the epoch the mint was read at, used to select the active transfer fee
+	Epoch uint64
+}
+
+func NewClassicMintInfo(mint solana.PublicKey, decimals uint8) *MintInfo {
+	return &MintInfo{
+		Mint:      mint,
+		ProgramId: solana.TokenProgramID,
+		Decimals:  decimals,
+	}
+}
+
+func (mint *MintInfo) IsToken2022() bool {
+	return mint.ProgramId.Equals(Token2022ProgramID)
+}
+
+func (mint *MintInfo) GetTransferFee() *TransferFee {
+	if mint.Extensions == nil || mint.Extensions.TransferFeeConfig == nil {
+		return nil
+	}
+	return mint.Extensions.TransferFeeConfig.GetEpochFee(mint.Epoch)
+}
+
+// ParseMintExtensions decodes the TLV extension area of a token-2022 mint account.
+// Mints without extensions return an empty result.
+func ParseMintExtensions(data []byte) (*MintExtensions, error) {
+	exts := &MintExtensions{Raw: make(map[ExtensionType][]byte)}
+	if len(data) <= mintBaseSize {
+		return exts, nil
+	}
+	if len(data) <= accountBaseSize {
+		return nil, fmt.Errorf("invalid mint data length: %d", len(data))
+	}
+	if data[accountBaseSize] != accountTypeMint {
+		return nil, fmt.Errorf("invalid account type: %d", data[accountBaseSize])
+	}
+
+	tlv := data[accountBaseSize+1:]
+	index := 0
+	for index+4 <= len(tlv) {
+		extType := ExtensionType(binary.LittleEndian.Uint16(tlv[index : index+2]))
+		length := int(binary.LittleEndian.Uint16(tlv[index+2 : index+4]))
+		index += 4
+		if extType == ExtensionUninitialized {
+			break
+		}
+		if index+length > len(tlv) {
+			return nil, fmt.Errorf("extension %d overflows data", extType)
+		}
+		value := tlv[index : index+length]
+		index += length
+
+		exts.Raw[extType] = value
+		if err := exts.decode(extType, value); err != nil {
+			return nil, fmt.Errorf("decode extension %d error: %w", extType, err)
+		}
+	}
+	return exts, nil
+}
+
+func (exts *MintExtensions) decode(extType ExtensionType, value []byte) error {
+	r := &tlvReader{data: value}
+	switch extType {
+	case ExtensionTransferFeeConfig:
+		cfg := &TransferFeeConfig{
+			TransferFeeConfigAuthority: r.pubkey(),
+			WithdrawWithheldAuthority:  r.pubkey(),
+			WithheldAmount:             r.u64(),
+			OlderTransferFee:           TransferFee{Epoch: r.u64(), MaximumFee: r.u64(), TransferFeeBasisPoints: r.u16()},
+			NewerTransferFee:           TransferFee{Epoch: r.u64(), MaximumFee: r.u64(), TransferFeeBasisPoints: r.u16()},
+		}
+		exts.TransferFeeConfig = cfg
+	case ExtensionMintCloseAuthority:
+		pk := r.pubkey()
+		exts.MintCloseAuthority = &pk
+	case ExtensionDefaultAccountState:
+		state := r.u8()
+		exts.DefaultAccountState = &state
+	case ExtensionNonTransferable:
+		exts.NonTransferable = true
+	case ExtensionInterestBearingConfig:
+		exts.InterestBearingConfig = &InterestBearingConfig{
+			RateAuthority:           r.pubkey(),
+			InitializationTimestamp: int64(r.u64()),
+			PreUpdateAverageRate:    int16(r.u16()),
+			LastUpdateTimestamp:     int64(r.u64()),
+			CurrentRate:             int16(r.u16()),
+		}
+	case ExtensionPermanentDelegate:
+		pk := r.pubkey()
+		exts.PermanentDelegate = &pk
+	case ExtensionTransferHook:
+		exts.TransferHook = &TransferHook{Authority: r.pubkey(), ProgramId: r.pubkey()}
+	case ExtensionMetadataPointer:
+		exts.MetadataPointer = &MetadataPointer{Authority: r.pubkey(), MetadataAddress: r.pubkey()}
+	case ExtensionTokenMetadata:
+		meta := &TokenMetadata{
+			UpdateAuthority: r.pubkey(),
+			Mint:            r.pubkey(),
+			Name:            r.str(),
+			Symbol:          r.str(),
+			Uri:             r.str(),
+		}
+		count := r.u32()
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			meta.AdditionalMetadata = append(meta.AdditionalMetadata, [2]string{r.str(), r.str()})
+		}
+		exts.TokenMetadata = meta
+	}
+	return r.err
+}
+
+type tlvReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *tlvReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("unexpected end of data at %d", r.pos)
+		return make([]byte, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *tlvReader) u8() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *tlvReader) u16() uint16 {
+	return binary.LittleEndian.Uint16(r.next(2))
+}
+
+func (r *tlvReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *tlvReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+func (r *tlvReader) pubkey() solana.PublicKey {
+	return solana.PublicKeyFromBytes(r.next(32))
+}
+
+func (r *tlvReader) str() string {
+	n := r.u32()
+	if r.err != nil || int(n) > len(r.data)-r.pos {
+		if r.err == nil {
+			r.err = fmt.Errorf("invalid string length %d at %d", n, r.pos)
+		}
+		return ""
+	}
+	return string(r.next(int(n)))
+}
+
+// NewTransferCheckedWithFeeInstruction builds the token-2022 TransferFeeExtension::TransferCheckedWithFee instruction.
+func NewTransferCheckedWithFeeInstruction(
+	amount uint64,
+	decimals uint8,
+	fee uint64,
+	source solana.PublicKey,
+	mint solana.PublicKey,
+	destination solana.PublicKey,
+	owner solana.PublicKey,
+) solana.Instruction {
+	data := make([]byte, 0, 19)
+	data = append(data, 26, 1)
+	data = binary.LittleEndian.AppendUint64(data, amount)
+	data = append(data, decimals)
+	data = binary.LittleEndian.AppendUint64(data, fee)
+
+	return solana.NewInstruction(
+		Token2022ProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(source).WRITE(),
+			solana.Meta(mint),
+			solana.Meta(destination).WRITE(),
+			solana.Meta(owner).SIGNER(),
+		},
+		data,
+	)
+}
+
+// withProgramId rebuilds an instruction from programs/token for another token program,
+// the two programs share the instruction layout for the classic instructions.
+func withProgramId(inst solana.Instruction, programId solana.PublicKey) (solana.Instruction, error) {
+	if inst.ProgramID().Equals(programId) {
+		return inst, nil
+	}
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewInstruction(programId, inst.Accounts(), data), nil
+}
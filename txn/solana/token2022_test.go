@@ -0,0 +1,65 @@
+package sol
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func appendTlv(data []byte, extType ExtensionType, value []byte) []byte {
+	data = binary.LittleEndian.AppendUint16(data, uint16(extType))
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(value)))
+	return append(data, value...)
+}
+
+func appendStr(data []byte, s string) []byte {
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+	return append(data, s...)
+}
+
+func TestParseMintExtensions(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+
+	data := make([]byte, accountBaseSize)
+	data = append(data, accountTypeMint)
+
+	fee := make([]byte, 72)
+	fee = binary.LittleEndian.AppendUint64(fee, 1)
+	fee = binary.LittleEndian.AppendUint64(fee, 1000)
+	fee = binary.LittleEndian.AppendUint16(fee, 10)
+	fee = binary.LittleEndian.AppendUint64(fee, 5)
+	fee = binary.LittleEndian.AppendUint64(fee, 5000)
+	fee = binary.LittleEndian.AppendUint16(fee, 50)
+	data = appendTlv(data, ExtensionTransferFeeConfig, fee)
+
+	pointer := append(make([]byte, 32), mint[:]...)
+	data = appendTlv(data, ExtensionMetadataPointer, pointer)
+
+	meta := append(make([]byte, 32), mint[:]...)
+	meta = appendStr(meta, "Owlto Token")
+	meta = appendStr(meta, "OWL")
+	meta = appendStr(meta, "https://owlto.finance")
+	meta = binary.LittleEndian.AppendUint32(meta, 1)
+	meta = appendStr(meta, "k")
+	meta = appendStr(meta, "v")
+	data = appendTlv(data, ExtensionTokenMetadata, meta)
+
+	exts, err := ParseMintExtensions(data)
+	assert.NoError(t, err)
+	assert.Len(t, exts.Raw, 3)
+	assert.Equal(t, mint, exts.MetadataPointer.MetadataAddress)
+	assert.Equal(t, "OWL", exts.TokenMetadata.Symbol)
+	assert.Equal(t, "Owlto Token", exts.TokenMetadata.Name)
+	assert.Equal(t, [][2]string{{"k", "v"}}, exts.TokenMetadata.AdditionalMetadata)
+
+	info := &MintInfo{Mint: mint, ProgramId: Token2022ProgramID, Decimals: 6, Extensions: exts, Epoch: 3}
+	assert.Equal(t, uint64(2), info.GetTransferFee().CalculateFee(1001))
+	info.Epoch = 5
+	assert.Equal(t, uint64(6), info.GetTransferFee().CalculateFee(1001))
+	assert.Equal(t, uint64(5000), info.GetTransferFee().CalculateFee(10000000))
+
+	_, err = ParseMintExtensions(data[:len(data)-3])
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package starknet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
+)
+
+var u128Mask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+type StarknetCall struct {
+	ContractAddress    *felt.Felt   `json:"contract_address"`
+	EntryPointSelector *felt.Felt   `json:"entry_point_selector"`
+	Calldata           []*felt.Felt `json:"calldata"`
+}
+
+// StarknetBody is an invoke of the sender account. Calldata is the __execute__ calldata of
+// the calls in the cairo 1 account encoding. Nonce and the fee fields, MaxFee for v1 and
+// ResourceBounds for v3, are filled by EstimateFee.
+type StarknetBody struct {
+	Version        rpc.TransactionVersion     `json:"version"`
+	SenderAddress  *felt.Felt                 `json:"sender_address"`
+	Calls          []StarknetCall             `json:"calls"`
+	Calldata       []*felt.Felt               `json:"calldata"`
+	Nonce          *felt.Felt                 `json:"nonce,omitempty"`
+	MaxFee         *felt.Felt                 `json:"max_fee,omitempty"`
+	ResourceBounds *rpc.ResourceBoundsMapping `json:"resource_bounds,omitempty"`
+}
+
+// U256ToFelts splits a u256 into its low and high 128 bits, the order cairo serializes them in
+func U256ToFelts(amount *big.Int) ([]*felt.Felt, error) {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid u256 %v", amount)
+	}
+	low := new(big.Int).And(amount, u128Mask)
+	high := new(big.Int).Rsh(amount, 128)
+	return []*felt.Felt{utils.BigIntToFelt(low), utils.BigIntToFelt(high)}, nil
+}
+
+func NewCall(contractAddr string, method string, calldata []*felt.Felt) (StarknetCall, error) {
+	contract, err := utils.HexToFelt(strings.TrimSpace(contractAddr))
+	if err != nil {
+		return StarknetCall{}, err
+	}
+	if calldata == nil {
+		calldata = []*felt.Felt{}
+	}
+	return StarknetCall{
+		ContractAddress:    contract,
+		EntryPointSelector: utils.GetSelectorFromNameFelt(method),
+		Calldata:           calldata,
+	}, nil
+}
+
+// MulticallCalldata encodes the calls as a cairo 1 account expects them: the number of
+// calls, then for each the contract, the selector, the calldata length and the calldata
+func MulticallCalldata(calls []StarknetCall) []*felt.Felt {
+	calldata := []*felt.Felt{new(felt.Felt).SetUint64(uint64(len(calls)))}
+	for _, call := range calls {
+		calldata = append(calldata, call.ContractAddress, call.EntryPointSelector, new(felt.Felt).SetUint64(uint64(len(call.Calldata))))
+		calldata = append(calldata, call.Calldata...)
+	}
+	return calldata
+}
+
+// ToBody builds an invoke of version rpc.TransactionV1 or rpc.TransactionV3
+func ToBody(version rpc.TransactionVersion, senderAddr string, calls []StarknetCall) ([]byte, error) {
+	body, err := ToStarknetBody(version, senderAddr, calls)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(body)
+}
+
+func ToStarknetBody(version rpc.TransactionVersion, senderAddr string, calls []StarknetCall) (*StarknetBody, error) {
+	if version != rpc.TransactionV1 && version != rpc.TransactionV3 {
+		return nil, fmt.Errorf("unsupported invoke version %s", version)
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no calls")
+	}
+	sender, err := utils.HexToFelt(strings.TrimSpace(senderAddr))
+	if err != nil {
+		return nil, err
+	}
+	return &StarknetBody{
+		Version:       version,
+		SenderAddress: sender,
+		Calls:         calls,
+		Calldata:      MulticallCalldata(calls),
+	}, nil
+}
+
+func InvokeBody(version rpc.TransactionVersion, senderAddr string, contractAddr string, method string, calldata []*felt.Felt) ([]byte, error) {
+	call, err := NewCall(contractAddr, method, calldata)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(version, senderAddr, []StarknetCall{call})
+}
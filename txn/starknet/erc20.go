@@ -0,0 +1,46 @@
+package starknet
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
+)
+
+func Erc20TransferCall(tokenAddr string, receiverAddr string, amount *big.Int) (StarknetCall, error) {
+	return erc20Call(tokenAddr, "transfer", receiverAddr, amount)
+}
+
+func Erc20ApproveCall(tokenAddr string, spenderAddr string, amount *big.Int) (StarknetCall, error) {
+	return erc20Call(tokenAddr, "approve", spenderAddr, amount)
+}
+
+func erc20Call(tokenAddr string, method string, addr string, amount *big.Int) (StarknetCall, error) {
+	account, err := utils.HexToFelt(strings.TrimSpace(addr))
+	if err != nil {
+		return StarknetCall{}, err
+	}
+	u256, err := U256ToFelts(amount)
+	if err != nil {
+		return StarknetCall{}, err
+	}
+	return NewCall(tokenAddr, method, append([]*felt.Felt{account}, u256...))
+}
+
+func Erc20TransferBody(version rpc.TransactionVersion, senderAddr string, tokenAddr string, receiverAddr string, amount *big.Int) ([]byte, error) {
+	call, err := Erc20TransferCall(tokenAddr, receiverAddr, amount)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(version, senderAddr, []StarknetCall{call})
+}
+
+func Erc20ApproveBody(version rpc.TransactionVersion, senderAddr string, tokenAddr string, spenderAddr string, amount *big.Int) ([]byte, error) {
+	call, err := Erc20ApproveCall(tokenAddr, spenderAddr, amount)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody(version, senderAddr, []StarknetCall{call})
+}
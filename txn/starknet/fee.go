@@ -0,0 +1,113 @@
+package starknet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
+)
+
+// FeeMultiplier scales the estimate into the max fee, or into both the max amount and max
+// price of the l1 gas for v3
+const FeeMultiplier = 1.5
+
+// EstimateFee fills the nonce of the sender when the body has none, and the fee fields from
+// starknet_estimateFee. The estimate skips the account validation as the body is unsigned.
+func EstimateFee(ctx context.Context, provider *rpc.Provider, body []byte) ([]byte, error) {
+	var starknetBody StarknetBody
+	if err := json.Unmarshal(body, &starknetBody); err != nil {
+		return nil, err
+	}
+	if err := starknetBody.EstimateFee(ctx, provider); err != nil {
+		return nil, err
+	}
+	return json.Marshal(starknetBody)
+}
+
+func (body *StarknetBody) EstimateFee(ctx context.Context, provider *rpc.Provider) error {
+	blockID := rpc.WithBlockTag("pending")
+	if body.Nonce == nil {
+		nonce, err := provider.Nonce(ctx, blockID, body.SenderAddress)
+		if err != nil {
+			return fmt.Errorf("get nonce of %v error %w", body.SenderAddress, err)
+		}
+		body.Nonce = nonce
+	}
+
+	var request rpc.BroadcastTxn
+	switch body.Version {
+	case rpc.TransactionV1:
+		request = rpc.BroadcastInvokev1Txn{InvokeTxnV1: rpc.InvokeTxnV1{
+			MaxFee:        new(felt.Felt),
+			Version:       rpc.TransactionV1WithQueryBit,
+			Signature:     []*felt.Felt{},
+			Nonce:         body.Nonce,
+			Type:          rpc.TransactionType_Invoke,
+			SenderAddress: body.SenderAddress,
+			Calldata:      body.Calldata,
+		}}
+	case rpc.TransactionV3:
+		request = rpc.BroadcastInvokev3Txn{InvokeTxnV3: newInvokeV3(body, rpc.TransactionV3WithQueryBit, zeroResourceBounds())}
+	default:
+		return fmt.Errorf("unsupported invoke version %s", body.Version)
+	}
+
+	estimates, err := provider.EstimateFee(ctx, []rpc.BroadcastTxn{request}, []rpc.SimulationFlag{rpc.SKIP_VALIDATE}, blockID)
+	if err != nil {
+		return fmt.Errorf("estimate fee of %v error %w", body.SenderAddress, err)
+	}
+	if len(estimates) != 1 {
+		return fmt.Errorf("estimate fee returned %d estimates", len(estimates))
+	}
+	estimate := estimates[0]
+
+	if body.Version == rpc.TransactionV1 {
+		body.MaxFee = utils.BigIntToFelt(mulFloat(utils.FeltToBigInt(estimate.OverallFee), FeeMultiplier))
+		return nil
+	}
+	// the overall fee includes the data gas, priced in l1 gas it bounds the amount of both
+	gasPrice := utils.FeltToBigInt(estimate.GasPrice)
+	if gasPrice.Sign() <= 0 {
+		return fmt.Errorf("estimate fee returned gas price %v", gasPrice)
+	}
+	maxAmount := new(big.Int).Add(utils.FeltToBigInt(estimate.OverallFee), new(big.Int).Sub(gasPrice, big.NewInt(1)))
+	maxAmount.Div(maxAmount, gasPrice)
+	bounds := zeroResourceBounds()
+	bounds.L1Gas = rpc.ResourceBounds{
+		MaxAmount:       rpc.U64(fmt.Sprintf("0x%x", mulFloat(maxAmount, FeeMultiplier))),
+		MaxPricePerUnit: rpc.U128(fmt.Sprintf("0x%x", mulFloat(utils.FeltToBigInt(estimate.GasPrice), FeeMultiplier))),
+	}
+	body.ResourceBounds = &bounds
+	return nil
+}
+
+func zeroResourceBounds() rpc.ResourceBoundsMapping {
+	zero := rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x0"}
+	return rpc.ResourceBoundsMapping{L1Gas: zero, L2Gas: zero}
+}
+
+func newInvokeV3(body *StarknetBody, version rpc.TransactionVersion, bounds rpc.ResourceBoundsMapping) rpc.InvokeTxnV3 {
+	return rpc.InvokeTxnV3{
+		Type:                  rpc.TransactionType_Invoke,
+		SenderAddress:         body.SenderAddress,
+		Calldata:              body.Calldata,
+		Version:               version,
+		Signature:             []*felt.Felt{},
+		Nonce:                 body.Nonce,
+		ResourceBounds:        bounds,
+		Tip:                   "0x0",
+		PayMasterData:         []*felt.Felt{},
+		AccountDeploymentData: []*felt.Felt{},
+		NonceDataMode:         rpc.DAModeL1,
+		FeeMode:               rpc.DAModeL1,
+	}
+}
+
+func mulFloat(value *big.Int, multiplier float64) *big.Int {
+	result := new(big.Int).Mul(value, big.NewInt(int64(multiplier*1000)))
+	return result.Div(result, big.NewInt(1000))
+}
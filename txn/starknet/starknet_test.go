@@ -0,0 +1,114 @@
+package starknet
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSender = "0x0123"
+	testToken  = "0x49d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7"
+)
+
+func newStubProvider(t *testing.T) *rpc.Provider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		switch req.Method {
+		case "starknet_getNonce":
+			result = "0x7"
+		case "starknet_estimateFee":
+			result = []map[string]string{{
+				"gas_consumed":      "0x64",
+				"gas_price":         "0x3e8",
+				"data_gas_consumed": "0x32",
+				"data_gas_price":    "0x3e8",
+				"overall_fee":       "0x249f0",
+				"unit":              "WEI",
+			}}
+		default:
+			t.Fatalf("unexpected method %s", req.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := ethrpc.DialContext(context.Background(), server.URL)
+	require.NoError(t, err)
+	return rpc.NewProvider(client)
+}
+
+func TestU256ToFelts(t *testing.T) {
+	amount := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(3), 128), big.NewInt(5))
+	felts, err := U256ToFelts(amount)
+	require.NoError(t, err)
+	assert.Equal(t, "0x5", felts[0].String())
+	assert.Equal(t, "0x3", felts[1].String())
+
+	_, err = U256ToFelts(big.NewInt(-1))
+	assert.Error(t, err)
+}
+
+func TestErc20TransferBody(t *testing.T) {
+	body, err := Erc20TransferBody(rpc.TransactionV1, testSender, testToken, "0x456", big.NewInt(1000))
+	require.NoError(t, err)
+
+	var starknetBody StarknetBody
+	require.NoError(t, json.Unmarshal(body, &starknetBody))
+	require.Len(t, starknetBody.Calls, 1)
+	assert.Equal(t, utils.GetSelectorFromNameFelt("transfer"), starknetBody.Calls[0].EntryPointSelector)
+
+	calldata := make([]string, 0, len(starknetBody.Calldata))
+	for _, f := range starknetBody.Calldata {
+		calldata = append(calldata, f.String())
+	}
+	assert.Equal(t, []string{
+		"0x1", testToken, utils.GetSelectorFromNameFelt("transfer").String(), "0x3", "0x456", "0x3e8", "0x0",
+	}, calldata)
+	assert.Nil(t, starknetBody.Nonce)
+	assert.Nil(t, starknetBody.MaxFee)
+}
+
+func TestEstimateFee(t *testing.T) {
+	provider := newStubProvider(t)
+
+	body, err := Erc20TransferBody(rpc.TransactionV1, testSender, testToken, "0x456", big.NewInt(1000))
+	require.NoError(t, err)
+	body, err = EstimateFee(context.Background(), provider, body)
+	require.NoError(t, err)
+	var v1 StarknetBody
+	require.NoError(t, json.Unmarshal(body, &v1))
+	assert.Equal(t, "0x7", v1.Nonce.String())
+	assert.Equal(t, "0x36ee8", v1.MaxFee.String())
+
+	body, err = Erc20ApproveBody(rpc.TransactionV3, testSender, testToken, "0x456", big.NewInt(1000))
+	require.NoError(t, err)
+	body, err = EstimateFee(context.Background(), provider, body)
+	require.NoError(t, err)
+	var v3 StarknetBody
+	require.NoError(t, json.Unmarshal(body, &v3))
+	require.NotNil(t, v3.ResourceBounds)
+	// 100 gas and 50 data gas at the same price, times 1.5
+	assert.Equal(t, rpc.U64("0xe1"), v3.ResourceBounds.L1Gas.MaxAmount)
+	assert.Equal(t, rpc.U128("0x5dc"), v3.ResourceBounds.L1Gas.MaxPricePerUnit)
+	assert.Equal(t, rpc.U64("0x0"), v3.ResourceBounds.L2Gas.MaxAmount)
+	assert.Nil(t, v3.MaxFee)
+
+	_, err = EstimateFee(context.Background(), provider, []byte(`{"version":"0x2"}`))
+	assert.Error(t, err)
+}
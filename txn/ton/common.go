@@ -0,0 +1,93 @@
+package ton
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// TonMessage is an internal message the sender wallet sends. Address is user friendly, its
+// bounce flag is the bounce flag of the message. Payload and StateInit are base64 bocs.
+type TonMessage struct {
+	Address   string `json:"address"`
+	Amount    string `json:"amount"`
+	Payload   string `json:"payload,omitempty"`
+	StateInit string `json:"state_init,omitempty"`
+}
+
+// TonBody is the messages of one wallet transfer, in the shape of a ton connect request
+type TonBody struct {
+	ValidUntil int64        `json:"valid_until,omitempty"`
+	Messages   []TonMessage `json:"messages"`
+}
+
+// ParseAddr accepts the raw workchain:hex form and the user friendly forms. The raw form
+// carries no flags and is parsed as non bounceable.
+func ParseAddr(addr string) (*address.Address, error) {
+	addr = strings.TrimSpace(addr)
+	if strings.Contains(addr, ":") {
+		parsed, err := address.ParseRawAddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ton address %s: %w", addr, err)
+		}
+		return parsed.Bounce(false), nil
+	}
+	parsed, err := address.ParseAddr(strings.NewReplacer("+", "-", "/", "_").Replace(addr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ton address %s: %w", addr, err)
+	}
+	return parsed, nil
+}
+
+// CommentCell is the text comment payload, a zero op followed by the snake encoded text
+func CommentCell(comment string) (*cell.Cell, error) {
+	builder := cell.BeginCell().MustStoreUInt(0, 32)
+	if err := builder.StoreStringSnake(comment); err != nil {
+		return nil, err
+	}
+	return builder.EndCell(), nil
+}
+
+func NewMessage(addr *address.Address, amount *big.Int, payload *cell.Cell) (TonMessage, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return TonMessage{}, fmt.Errorf("invalid amount %v", amount)
+	}
+	message := TonMessage{
+		Address: addr.String(),
+		Amount:  amount.String(),
+	}
+	if payload != nil {
+		message.Payload = base64.StdEncoding.EncodeToString(payload.ToBOC())
+	}
+	return message, nil
+}
+
+func ToBody(messages []TonMessage) ([]byte, error) {
+	return json.Marshal(TonBody{Messages: messages})
+}
+
+// TransferBody sends amount nanotons with an optional comment. A raw receiver address is
+// sent to without bounce so the amount reaches wallets not deployed yet.
+func TransferBody(receiverAddr string, amount *big.Int, comment string) ([]byte, error) {
+	receiver, err := ParseAddr(receiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	var payload *cell.Cell
+	if comment != "" {
+		payload, err = CommentCell(comment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message, err := NewMessage(receiver, amount, payload)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody([]TonMessage{message})
+}
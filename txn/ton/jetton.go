@@ -0,0 +1,92 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	jettonTransferOp = 0x0f8a7ea5
+	// JettonTransferGas is the nanotons attached on top of the forward amount to pay the
+	// jetton wallets, the excess comes back to the response address
+	JettonTransferGas = 50_000_000
+)
+
+// SenderJettonWallet is the jetton wallet of the sender, the contract a jetton transfer is sent to
+func SenderJettonWallet(ctx context.Context, tonRpc *rpc.TonRpc, senderAddr string, jettonAddr string) (string, error) {
+	wallet, err := tonRpc.GetJettonWallet(ctx, senderAddr, jettonAddr)
+	if err != nil {
+		return "", err
+	}
+	if wallet == nil {
+		return "", fmt.Errorf("%s has no wallet of jetton %s", senderAddr, jettonAddr)
+	}
+	return wallet.Address, nil
+}
+
+// JettonTransferPayload is the TEP-74 transfer message body. The receiver is notified with
+// forwardTonAmount nanotons and forwardPayload, which may be nil, when forwardTonAmount is positive.
+func JettonTransferPayload(receiverAddr string, responseAddr string, amount *big.Int, forwardTonAmount *big.Int, forwardPayload *cell.Cell) (*cell.Cell, error) {
+	receiver, err := ParseAddr(receiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ParseAddr(responseAddr)
+	if err != nil {
+		return nil, err
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %v", amount)
+	}
+	if forwardTonAmount == nil {
+		forwardTonAmount = big.NewInt(0)
+	}
+
+	builder := cell.BeginCell().
+		MustStoreUInt(jettonTransferOp, 32).
+		MustStoreUInt(uint64(time.Now().UnixNano()), 64)
+	if err := builder.StoreBigCoins(amount); err != nil {
+		return nil, err
+	}
+	builder.MustStoreAddr(receiver).
+		MustStoreAddr(response).
+		MustStoreMaybeRef(nil)
+	if err := builder.StoreBigCoins(forwardTonAmount); err != nil {
+		return nil, err
+	}
+	// the forward payload always goes in a reference, it may not fit in the remaining bits
+	if forwardPayload != nil {
+		builder.MustStoreBoolBit(true).MustStoreRef(forwardPayload)
+	} else {
+		builder.MustStoreBoolBit(false)
+	}
+	return builder.EndCell(), nil
+}
+
+// JettonTransferBody sends amount jettons from the sender jetton wallet to the receiver, the
+// excess gas goes back to responseAddr, usually the sender.
+func JettonTransferBody(jettonWalletAddr string, receiverAddr string, responseAddr string, amount *big.Int, forwardTonAmount *big.Int, forwardPayload *cell.Cell) ([]byte, error) {
+	jettonWallet, err := ParseAddr(jettonWalletAddr)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := JettonTransferPayload(receiverAddr, responseAddr, amount, forwardTonAmount, forwardPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	value := big.NewInt(JettonTransferGas)
+	if forwardTonAmount != nil {
+		value.Add(value, forwardTonAmount)
+	}
+	message, err := NewMessage(jettonWallet.Bounce(true), value, payload)
+	if err != nil {
+		return nil, err
+	}
+	return ToBody([]TonMessage{message})
+}
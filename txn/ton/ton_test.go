@@ -0,0 +1,81 @@
+package ton
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	testReceiverRaw = "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"
+	testWalletRaw   = "0:b113a994b5024a16719f69139328eb759596c38a25f59028b146fecdc3621dfe"
+)
+
+func decodeBody(t *testing.T, body []byte) (TonMessage, *cell.Slice) {
+	var tonBody TonBody
+	require.NoError(t, json.Unmarshal(body, &tonBody))
+	require.Len(t, tonBody.Messages, 1)
+	message := tonBody.Messages[0]
+	boc, err := base64.StdEncoding.DecodeString(message.Payload)
+	require.NoError(t, err)
+	payload, err := cell.FromBOC(boc)
+	require.NoError(t, err)
+	return message, payload.BeginParse()
+}
+
+func TestTransferBody(t *testing.T) {
+	body, err := TransferBody(testReceiverRaw, big.NewInt(1_000_000_000), "0xabc")
+	require.NoError(t, err)
+	message, payload := decodeBody(t, body)
+	assert.Equal(t, "1000000000", message.Amount)
+
+	receiver, err := ParseAddr(message.Address)
+	require.NoError(t, err)
+	assert.False(t, receiver.IsBounceable())
+	assert.Equal(t, testReceiverRaw, "0:"+hex.EncodeToString(receiver.Data()))
+
+	assert.Equal(t, uint64(0), payload.MustLoadUInt(32))
+	comment, err := payload.LoadStringSnake()
+	require.NoError(t, err)
+	assert.Equal(t, "0xabc", comment)
+
+	body, err = TransferBody(testReceiverRaw, big.NewInt(1), "")
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "payload")
+
+	_, err = TransferBody("not an address", big.NewInt(1), "")
+	assert.Error(t, err)
+}
+
+func TestJettonTransferBody(t *testing.T) {
+	forwardPayload, err := CommentCell("0xabc")
+	require.NoError(t, err)
+	body, err := JettonTransferBody(testWalletRaw, testReceiverRaw, testWalletRaw, big.NewInt(2_500_000), big.NewInt(1), forwardPayload)
+	require.NoError(t, err)
+	message, payload := decodeBody(t, body)
+	assert.Equal(t, "50000001", message.Amount)
+
+	wallet, err := ParseAddr(message.Address)
+	require.NoError(t, err)
+	assert.True(t, wallet.IsBounceable())
+
+	assert.Equal(t, uint64(jettonTransferOp), payload.MustLoadUInt(32))
+	payload.MustLoadUInt(64)
+	assert.Equal(t, big.NewInt(2_500_000), payload.MustLoadBigCoins())
+	assert.Equal(t, testReceiverRaw, "0:"+hex.EncodeToString(payload.MustLoadAddr().Data()))
+	payload.MustLoadAddr()
+	assert.False(t, payload.MustLoadBoolBit())
+	assert.Equal(t, big.NewInt(1), payload.MustLoadBigCoins())
+	assert.True(t, payload.MustLoadBoolBit())
+	forward := payload.MustLoadRef()
+	assert.Equal(t, uint64(0), forward.MustLoadUInt(32))
+	comment, err := forward.LoadStringSnake()
+	require.NoError(t, err)
+	assert.Equal(t, "0xabc", comment)
+}
@@ -0,0 +1,108 @@
+package tron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+// MaxFeeLimit bounds the estimated fee limit, in sun, at 100 TRX
+const MaxFeeLimit = 100_000_000
+
+const (
+	TxTypeTransfer             = "TransferContract"
+	TxTypeTriggerSmartContract = "TriggerSmartContract"
+)
+
+// TronBody carries the params of the http api creating the unsigned tx, /wallet/createtransaction
+// for TransferContract and /wallet/triggersmartcontract for TriggerSmartContract. Addresses are
+// base58, amounts and the fee limit in sun.
+type TronBody struct {
+	TxType           string `json:"tx_type"`
+	OwnerAddress     string `json:"owner_address"`
+	ToAddress        string `json:"to_address,omitempty"`
+	Amount           int64  `json:"amount,omitempty"`
+	ContractAddress  string `json:"contract_address,omitempty"`
+	FunctionSelector string `json:"function_selector,omitempty"`
+	Parameter        string `json:"parameter,omitempty"`
+	FeeLimit         int64  `json:"fee_limit,omitempty"`
+	CallValue        int64  `json:"call_value,omitempty"`
+	Visible          bool   `json:"visible"`
+}
+
+func normalize(addr string) (string, error) {
+	return address.Normalize(loader.TronBackend, "", addr)
+}
+
+// TransferBody sends amount sun of TRX
+func TransferBody(senderAddr string, receiverAddr string, amount *big.Int) ([]byte, error) {
+	sender, err := normalize(senderAddr)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := normalize(receiverAddr)
+	if err != nil {
+		return nil, err
+	}
+	if amount == nil || amount.Sign() <= 0 || !amount.IsInt64() {
+		return nil, fmt.Errorf("invalid amount %v", amount)
+	}
+	return json.Marshal(TronBody{
+		TxType:       TxTypeTransfer,
+		OwnerAddress: sender,
+		ToAddress:    receiver,
+		Amount:       amount.Int64(),
+		Visible:      true,
+	})
+}
+
+// ToBody calls the contract with the selector and hex parameter of rpc.TronAbiCall, burning
+// at most feeLimit sun for energy
+func ToBody(senderAddr string, contractAddr string, selector string, parameter string, feeLimit int64) ([]byte, error) {
+	sender, err := normalize(senderAddr)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := normalize(contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	if feeLimit <= 0 {
+		return nil, fmt.Errorf("invalid fee limit %d", feeLimit)
+	}
+	return json.Marshal(TronBody{
+		TxType:           TxTypeTriggerSmartContract,
+		OwnerAddress:     sender,
+		ContractAddress:  contract,
+		FunctionSelector: selector,
+		Parameter:        parameter,
+		FeeLimit:         feeLimit,
+		Visible:          true,
+	})
+}
+
+// EstimateFeeLimit prices the energy the call uses at the chain energy price, with the same
+// margin as evm.EstimateGas. An estimate above MaxFeeLimit is an error, the tx would run out
+// of energy under the cap. Energy staked by the sender is not accounted, it only lowers what
+// is burnt.
+func EstimateFeeLimit(ctx context.Context, tronRpc *rpc.TronRpc, senderAddr string, contractAddr string, selector string, parameter string) (int64, error) {
+	result, err := tronRpc.TriggerConstantContract(ctx, senderAddr, contractAddr, selector, parameter)
+	if err != nil {
+		return 0, err
+	}
+	price, err := tronRpc.GetEnergyPrice(ctx)
+	if err != nil {
+		return 0, err
+	}
+	feeLimit := result.EnergyUsed * price * 3 / 2
+	if feeLimit > MaxFeeLimit {
+		return 0, fmt.Errorf("%v %v fee limit %d exceeds %d", strings.TrimSpace(contractAddr), selector, feeLimit, MaxFeeLimit)
+	}
+	return feeLimit, nil
+}
@@ -0,0 +1,40 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/owlto-dao/utils-go/rpc"
+)
+
+// Trc20TransferBody transfers amount of the token, a feeLimit of 0 is estimated by EstimateFeeLimit
+func Trc20TransferBody(ctx context.Context, tronRpc *rpc.TronRpc, senderAddr string, tokenAddr string, receiverAddr string, amount *big.Int, feeLimit int64) ([]byte, error) {
+	return trc20Body(ctx, tronRpc, senderAddr, tokenAddr, feeLimit, "transfer", receiverAddr, amount)
+}
+
+// Trc20ApproveBody approves the spender for amount of the token, a feeLimit of 0 is estimated
+func Trc20ApproveBody(ctx context.Context, tronRpc *rpc.TronRpc, senderAddr string, tokenAddr string, spenderAddr string, amount *big.Int, feeLimit int64) ([]byte, error) {
+	return trc20Body(ctx, tronRpc, senderAddr, tokenAddr, feeLimit, "approve", spenderAddr, amount)
+}
+
+func trc20Body(ctx context.Context, tronRpc *rpc.TronRpc, senderAddr string, tokenAddr string, feeLimit int64, method string, addr string, amount *big.Int) ([]byte, error) {
+	addr, err := normalize(addr)
+	if err != nil {
+		return nil, err
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %v", amount)
+	}
+	selector, parameter, err := rpc.TronAbiCall(method, addr, amount)
+	if err != nil {
+		return nil, err
+	}
+	if feeLimit <= 0 {
+		feeLimit, err = EstimateFeeLimit(ctx, tronRpc, senderAddr, tokenAddr, selector, parameter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ToBody(senderAddr, tokenAddr, selector, parameter, feeLimit)
+}
@@ -0,0 +1,90 @@
+package tron
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/address"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUsdt = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+
+func newStubRpc(t *testing.T, energyUsed int64) *rpc.TronRpc {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wallet/triggerconstantcontract", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]bool{"result": true}, "energy_used": energyUsed, "constant_result": []string{""}})
+	})
+	mux.HandleFunc("/wallet/getchainparameters", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"chainParameter": []map[string]interface{}{{"key": "getEnergyFee", "value": 210}}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return rpc.NewTronRpcFromConfig(&loader.ChainInfo{Name: "TronMainnet", RpcEndPoint: server.URL}, rpc.TronConfig{})
+}
+
+func testAddress(t *testing.T, b string) string {
+	addr, err := address.TronHexToBase58("41" + strings.Repeat(b, 20))
+	require.NoError(t, err)
+	return addr
+}
+
+func TestTransferBody(t *testing.T) {
+	sender, receiver := testAddress(t, "11"), testAddress(t, "22")
+	body, err := TransferBody(sender, "41"+strings.Repeat("22", 20), big.NewInt(1_000_000))
+	require.NoError(t, err)
+
+	var tronBody TronBody
+	require.NoError(t, json.Unmarshal(body, &tronBody))
+	assert.Equal(t, TxTypeTransfer, tronBody.TxType)
+	assert.Equal(t, sender, tronBody.OwnerAddress)
+	assert.Equal(t, receiver, tronBody.ToAddress)
+	assert.Equal(t, int64(1_000_000), tronBody.Amount)
+	assert.True(t, tronBody.Visible)
+
+	_, err = TransferBody(sender, receiver, big.NewInt(0))
+	assert.Error(t, err)
+}
+
+func TestTrc20Body(t *testing.T) {
+	sender, receiver := testAddress(t, "11"), testAddress(t, "22")
+
+	body, err := Trc20TransferBody(context.TODO(), newStubRpc(t, 65_000), sender, testUsdt, receiver, big.NewInt(2_500_000), 0)
+	require.NoError(t, err)
+	var tronBody TronBody
+	require.NoError(t, json.Unmarshal(body, &tronBody))
+	assert.Equal(t, TxTypeTriggerSmartContract, tronBody.TxType)
+	assert.Equal(t, testUsdt, tronBody.ContractAddress)
+	assert.Equal(t, "transfer(address,uint256)", tronBody.FunctionSelector)
+	assert.Equal(t, strings.Repeat("0", 24)+strings.Repeat("22", 20)+strings.Repeat("0", 58)+"2625a0", tronBody.Parameter)
+	assert.Equal(t, int64(65_000*210*3/2), tronBody.FeeLimit)
+
+	body, err = Trc20ApproveBody(context.TODO(), newStubRpc(t, 300_000), sender, testUsdt, receiver, big.NewInt(1), 0)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &tronBody))
+	assert.Equal(t, "approve(address,uint256)", tronBody.FunctionSelector)
+	assert.Equal(t, int64(300_000*210*3/2), tronBody.FeeLimit)
+
+	// an estimate above the cap fails instead of sending a tx that runs out of energy
+	_, err = Trc20ApproveBody(context.TODO(), newStubRpc(t, 1_000_000), sender, testUsdt, receiver, big.NewInt(1), 0)
+	assert.ErrorContains(t, err, "exceeds")
+
+	body, err = Trc20TransferBody(context.TODO(), nil, sender, testUsdt, receiver, big.NewInt(1), 30_000_000)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &tronBody))
+	assert.Equal(t, int64(30_000_000), tronBody.FeeLimit)
+
+	_, err = Trc20TransferBody(context.TODO(), nil, sender, testUsdt, "0x1234", big.NewInt(1), 30_000_000)
+	assert.Error(t, err)
+	// an evm receiver is not silently converted to the tron account of the same bytes
+	_, err = TransferBody(sender, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", big.NewInt(1))
+	assert.Error(t, err)
+}